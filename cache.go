@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// cacheDirEnv 覆盖缓存目录的环境变量
+	cacheDirEnv = "GO2V_CACHE_DIR"
+	// defaultMetadataTTL 发布元数据缓存的默认有效期
+	defaultMetadataTTL = time.Hour
+	// metadataCachePrefix 元数据缓存文件名前缀，后接来源地址的哈希
+	metadataCachePrefix = "releases"
+	// archivesCacheDirName 缓存已下载归档的子目录名
+	archivesCacheDirName = "archives"
+)
+
+// metadataCacheEntry 缓存的发布元数据及其 HTTP 校验信息
+type metadataCacheEntry struct {
+	URL          string          `json:"url"`                     // URL 元数据的来源地址
+	ETag         string          `json:"etag,omitempty"`          // ETag 响应中的 ETag，用于 If-None-Match
+	LastModified string          `json:"last_modified,omitempty"` // LastModified 响应中的 Last-Modified，用于 If-Modified-Since
+	FetchedAt    time.Time       `json:"fetched_at"`              // FetchedAt 最近一次从服务器获取或确认未变化的时间
+	Body         json.RawMessage `json:"body"`                    // Body 原始 JSON 响应
+}
+
+// Age 返回缓存距最近一次确认的时间
+func (e *metadataCacheEntry) Age() time.Duration {
+	return time.Since(e.FetchedAt)
+}
+
+// cacheDir 返回 go2v 的缓存目录，可通过 GO2V_CACHE_DIR 覆盖，默认为用户缓存目录下的 go2v
+func cacheDir() (string, error) {
+	if dir := os.Getenv(cacheDirEnv); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine cache directory (set %s): %w", cacheDirEnv, err)
+	}
+	return filepath.Join(dir, toolchainsDirName), nil
+}
+
+// metadataCachePath 返回 versionURL 对应的元数据缓存文件路径，每个来源地址使用独立的缓存文件
+func metadataCachePath(versionURL string) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(versionURL))
+	return filepath.Join(dir, fmt.Sprintf("%s-%s.json", metadataCachePrefix, hex.EncodeToString(sum[:8]))), nil
+}
+
+// archiveCacheDir 返回缓存已下载归档 (及其 .asc 签名) 的目录
+func archiveCacheDir() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, archivesCacheDirName), nil
+}
+
+// loadMetadataCache 读取元数据缓存，不存在或无法解析时返回 nil
+func loadMetadataCache(path string) *metadataCacheEntry {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			debugPrint("Failed to read metadata cache %s: %v", path, err)
+		}
+		return nil
+	}
+	var entry metadataCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || len(entry.Body) == 0 {
+		debugPrint("Ignoring corrupt metadata cache %s: %v", path, err)
+		return nil
+	}
+	return &entry
+}
+
+// saveMetadataCache 原子地写入元数据缓存
+func saveMetadataCache(path string, entry *metadataCacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic 先写临时文件再 rename，避免并发运行的 go2v 读到写了一半的缓存文件
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testReleaseJSON 假服务器返回的版本列表
+const testReleaseJSON = `[{"version":"go1.23.1","stable":true,"files":[]},{"version":"go1.22.7","stable":true,"files":[]}]`
+
+// testMetadataServer 支持 ETag / Last-Modified 条件请求的假元数据服务器
+type testMetadataServer struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	etag         string // etag 为空时不发送 ETag
+	lastModified string // lastModified 为空时不发送 Last-Modified
+	status       int    // status 非 0 时直接返回该状态码
+	requests     []http.Header
+}
+
+// newTestMetadataServer 启动假元数据服务器，测试结束时关闭
+func newTestMetadataServer(t *testing.T, etag, lastModified string) *testMetadataServer {
+	t.Helper()
+	s := &testMetadataServer{etag: etag, lastModified: lastModified}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests = append(s.requests, r.Header.Clone())
+		if s.status != 0 {
+			w.WriteHeader(s.status)
+			return
+		}
+		if s.etag != "" {
+			w.Header().Set("ETag", s.etag)
+		}
+		if s.lastModified != "" {
+			w.Header().Set("Last-Modified", s.lastModified)
+		}
+		if (s.etag != "" && r.Header.Get("If-None-Match") == s.etag) ||
+			(s.lastModified != "" && r.Header.Get("If-Modified-Since") == s.lastModified) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(testReleaseJSON))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// received 返回服务器收到的请求头
+func (s *testMetadataServer) received() []http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]http.Header(nil), s.requests...)
+}
+
+// setStatus 让之后的请求都返回 status
+func (s *testMetadataServer) setStatus(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+// useTestMetadataCache 使用临时缓存目录，并在测试结束后恢复缓存相关的全局设置
+func useTestMetadataCache(t *testing.T, ttl time.Duration) {
+	t.Helper()
+	t.Setenv(cacheDirEnv, t.TempDir())
+	oldTTL, oldRefresh, oldOffline, oldRetries := metadataTTL, refreshMetadata, offlineMode, httpRetries
+	t.Cleanup(func() {
+		metadataTTL, refreshMetadata, offlineMode, httpRetries = oldTTL, oldRefresh, oldOffline, oldRetries
+	})
+	metadataTTL, refreshMetadata, offlineMode, httpRetries = ttl, false, false, 0
+}
+
+// mustFetchReleaseList 调用 fetchReleaseList 并确认返回了测试版本列表
+func mustFetchReleaseList(t *testing.T, url string) {
+	t.Helper()
+	versions, err := fetchReleaseList(url, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0].Version != "go1.23.1" {
+		t.Fatalf("versions = %+v, want the test release list", versions)
+	}
+}
+
+func TestFetchReleaseListUsesFreshCache(t *testing.T) {
+	useTestMetadataCache(t, time.Hour)
+	srv := newTestMetadataServer(t, `"v1"`, "")
+
+	mustFetchReleaseList(t, srv.URL)
+	mustFetchReleaseList(t, srv.URL)
+	if n := len(srv.received()); n != 1 {
+		t.Errorf("server received %d requests, want 1 (second call served from cache)", n)
+	}
+}
+
+func TestFetchReleaseListRevalidates(t *testing.T) {
+	tests := []struct {
+		name         string
+		etag         string
+		lastModified string
+		header       string // header 重新验证时应携带的条件请求头
+		want         string
+	}{
+		{"etag", `"v1"`, "", "If-None-Match", `"v1"`},
+		{"last-modified", "", "Tue, 02 Apr 2024 12:00:00 GMT", "If-Modified-Since", "Tue, 02 Apr 2024 12:00:00 GMT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 有效期为 0：每次都向服务器重新验证
+			useTestMetadataCache(t, 0)
+			srv := newTestMetadataServer(t, tt.etag, tt.lastModified)
+
+			mustFetchReleaseList(t, srv.URL)
+			path, _ := metadataCachePath(srv.URL)
+			first := loadMetadataCache(path)
+			mustFetchReleaseList(t, srv.URL)
+
+			requests := srv.received()
+			if len(requests) != 2 {
+				t.Fatalf("server received %d requests, want 2", len(requests))
+			}
+			if got := requests[1].Get(tt.header); got != tt.want {
+				t.Errorf("%s = %q, want %q", tt.header, got, tt.want)
+			}
+			// 304 后缓存内容不变，确认时间更新
+			second := loadMetadataCache(path)
+			if string(second.Body) != string(first.Body) || !second.FetchedAt.After(first.FetchedAt) {
+				t.Errorf("cache after 304 = %+v, want the same body with a newer FetchedAt than %v", second, first.FetchedAt)
+			}
+		})
+	}
+}
+
+func TestFetchReleaseListRefreshSkipsConditionalRequest(t *testing.T) {
+	useTestMetadataCache(t, time.Hour)
+	srv := newTestMetadataServer(t, `"v1"`, "")
+
+	mustFetchReleaseList(t, srv.URL)
+	refreshMetadata = true
+	mustFetchReleaseList(t, srv.URL)
+	requests := srv.received()
+	if len(requests) != 2 || requests[1].Get("If-None-Match") != "" {
+		t.Errorf("requests = %v, want an unconditional second request", requests)
+	}
+}
+
+func TestFetchReleaseListFallsBackToStaleCache(t *testing.T) {
+	tests := []struct {
+		name        string
+		breakServer func(*testMetadataServer)
+	}{
+		{"server error", func(s *testMetadataServer) { s.setStatus(http.StatusInternalServerError) }},
+		{"server down", func(s *testMetadataServer) { s.Close() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestMetadataCache(t, 0)
+			srv := newTestMetadataServer(t, `"v1"`, "")
+			mustFetchReleaseList(t, srv.URL)
+
+			tt.breakServer(srv)
+			mustFetchReleaseList(t, srv.URL)
+		})
+	}
+}
+
+func TestFetchReleaseListErrorsWithoutCache(t *testing.T) {
+	useTestMetadataCache(t, time.Hour)
+	srv := newTestMetadataServer(t, `"v1"`, "")
+	srv.setStatus(http.StatusInternalServerError)
+
+	_, err := fetchReleaseList(srv.URL, "")
+	if err == nil || !strings.Contains(err.Error(), "status code: 500") {
+		t.Errorf("error = %v, want a status code error", err)
+	}
+}
+
+func TestFetchReleaseListOffline(t *testing.T) {
+	useTestMetadataCache(t, 0)
+	srv := newTestMetadataServer(t, `"v1"`, "")
+	mustFetchReleaseList(t, srv.URL+"/all")
+
+	// 离线时不访问网络，使用过期的缓存；当前列表没有缓存时使用完整列表的缓存
+	offlineMode = true
+	versions, err := fetchReleaseList(srv.URL+"/current", srv.URL+"/all")
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("fetchReleaseList offline = %v, %v", versions, err)
+	}
+	if n := len(srv.received()); n != 1 {
+		t.Errorf("server received %d requests, want 1", n)
+	}
+	if _, err := fetchReleaseList(srv.URL+"/other", ""); err == nil {
+		t.Error("expected an error offline without any cache")
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// version go2v 自身的版本号，发布构建时通过 -ldflags "-X main.version=..." 注入
+var version = "dev"
+
+// command 表示一个子命令
+type command struct {
+	Name    string                  // Name 子命令名
+	Summary string                  // Summary 在总帮助中显示的一行说明
+	Run     func(args []string) int // Run 执行子命令，返回进程退出码
+}
+
+// commands 所有子命令，按帮助中的显示顺序排列
+var commands = []command{
+	{Name: "install", Summary: "Download and install a Go version (default command)", Run: runInstall},
+	{Name: "workspace", Summary: "Find the highest Go version required by go.work and all go.mod files", Run: runWorkspace},
+	{Name: "list", Summary: "List installed Go versions", Run: runList},
+	{Name: "list-remote", Summary: "List Go versions available for download", Run: runListRemote},
+	{Name: "use", Summary: "Switch the active Go version", Run: runUse},
+	{Name: "uninstall", Summary: "Remove an installed Go version", Run: runUninstall},
+	{Name: "update", Summary: "Install the latest stable Go version if it is newer", Run: runUpdate},
+	{Name: "env", Summary: "Print shell environment settings for the active Go version", Run: runEnv},
+	{Name: "doctor", Summary: "Diagnose the go2v installation and PATH setup", Run: runDoctor},
+	{Name: "self-update", Summary: "Update go2v itself to the latest release", Run: runSelfUpdate},
+}
+
+// runCLI 解析子命令并执行，返回进程退出码
+// 不带子命令或第一个参数为 flag 时 (例如 "go2v -v 1.22.2")，与旧版本一样执行安装
+func runCLI(args []string) int {
+	if len(args) == 0 || (strings.HasPrefix(args[0], "-") && !isHelpArg(args[0])) {
+		return runInstall(args)
+	}
+
+	name := args[0]
+	if isHelpArg(name) || name == "help" {
+		if name == "help" && len(args) > 1 {
+			if cmd := findCommand(args[1]); cmd != nil {
+				return cmd.Run([]string{"-h"})
+			}
+			fmt.Fprintf(os.Stderr, "Error: Unknown command %q\n\n", args[1])
+			printUsage()
+			return 2
+		}
+		printUsage()
+		return 0
+	}
+
+	cmd := findCommand(name)
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "Error: Unknown command %q\n\n", name)
+		printUsage()
+		return 2
+	}
+	return cmd.Run(args[1:])
+}
+
+// findCommand 按名称查找子命令
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].Name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// isHelpArg 判断参数是否为帮助 flag
+func isHelpArg(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+// printUsage 打印总帮助信息
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "go2v %s - a simple Go version manager\n\n", version)
+	fmt.Fprintf(os.Stderr, "Usage:\n  go2v <command> [flags] [args]\n  go2v [install flags]        (same as 'go2v install')\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", cmd.Name, cmd.Summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'go2v <command> -h' for help on a command.\n")
+}
+
+// newCommandFlagSet 创建子命令的 FlagSet，并注册所有子命令通用的 --debug flag
+func newCommandFlagSet(name, argsUsage, description string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.BoolVar(&debugMode, "debug", false, "Enable debug mode for verbose output.")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: go2v %s %s\n\n%s\n\nFlags:\n", name, argsUsage, description)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// registerRootFlag 注册 --root flag，用于操作 /usr/local/go2v 下的全局工具链
+func registerRootFlag(fs *flag.FlagSet) {
+	fs.BoolVar(&rootMode, "root", false, "Operate on the global toolchains in /usr/local/go2v and configure PATH globally (requires root privileges).")
+}
+
+// registerMetadataFlags 注册版本来源和发布元数据缓存相关的 flag，供所有需要查询可用版本的子命令共用
+func registerMetadataFlags(fs *flag.FlagSet) {
+	fs.DurationVar(&metadataTTL, "cache-ttl", defaultMetadataTTL, "How long cached release metadata is used before revalidating it with the server (0 = always revalidate).")
+	fs.BoolVar(&refreshMetadata, "refresh", false, "Ignore cached release metadata and fetch it again.")
+	fs.Var(&sourceSpecs, "source", "Where to get releases from: \"go.dev\", a base URL with the go.dev/dl/ layout, or a local directory of archives. Can be specified multiple times; later sources are used when earlier ones fail (default: $GO2V_SOURCE or go.dev).")
+	fs.BoolVar(&offlineMode, "offline", false, "Never access the network: resolve versions from cached release metadata and install only archives already in the local cache.")
+	registerNetworkFlags(fs)
+}
+
+// registerNetworkFlags 注册网络请求相关的 flag，供所有访问网络的子命令共用
+func registerNetworkFlags(fs *flag.FlagSet) {
+	fs.IntVar(&httpRetries, "retries", defaultHTTPRetries, "How many times to retry a request after a network error, a 5xx or a 429 response, with jittered exponential backoff (0 = no retries).")
+}
+
+// openToolchainStore 根据 --root 和当前权限返回用户级或全局的工具链存储
+func openToolchainStore() (homeDir string, store *toolchainStore, global bool, err error) {
+	homeDir, err = os.UserHomeDir()
+	if err != nil {
+		return "", nil, false, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	global = rootMode && os.Geteuid() == 0
+	if rootMode && !global {
+		fmt.Println("Warning: --root flag set, but not running with root privileges. Using user toolchains.")
+	}
+	store = newToolchainStore(homeDir, global)
+	debugPrint("Using toolchain directory: %s", store.Root)
+	return homeDir, store, global, nil
+}
+
+// detectGoArch 检测当前系统对应的 GOARCH，无法检测时回退到构建时的 runtime.GOARCH
+// verbose 为 true 时打印检测到的系统信息
+func detectGoArch(verbose bool) (string, error) {
+	kernelVersion, detectedArchitecture, err := getSystemInfo()
+	if err != nil {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Error: Failed to get system information: %v\n", err)
+			fmt.Printf("Warning: Will use Go's build time system and architecture (%s/%s)\n", runtime.GOOS, runtime.GOARCH)
+		}
+		return runtime.GOARCH, nil
+	}
+
+	if verbose {
+		fmt.Printf("System Info: Kernel Version %s, Detected Architecture %s\n", kernelVersion, detectedArchitecture)
+	}
+	goArch := mapArchitecture(detectedArchitecture)
+	if goArch == "" {
+		return "", fmt.Errorf("could not map detected architecture '%s' to a supported Go architecture", detectedArchitecture)
+	}
+	if verbose {
+		fmt.Printf("Mapped Go Architecture: %s\n", goArch)
+	}
+	return goArch, nil
+}
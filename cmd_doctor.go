@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// doctorReport 收集诊断结果
+type doctorReport struct {
+	failures int
+	warnings int
+}
+
+// ok 打印通过的检查项
+func (r *doctorReport) ok(format string, a ...interface{}) {
+	fmt.Printf("[ OK ] "+format+"\n", a...)
+}
+
+// warn 打印警告项
+func (r *doctorReport) warn(format string, a ...interface{}) {
+	r.warnings++
+	fmt.Printf("[WARN] "+format+"\n", a...)
+}
+
+// fail 打印失败项
+func (r *doctorReport) fail(format string, a ...interface{}) {
+	r.failures++
+	fmt.Printf("[FAIL] "+format+"\n", a...)
+}
+
+// runDoctor 实现 "go2v doctor" 子命令：检查工具链目录、激活版本、PATH 配置和网络连通性
+func runDoctor(args []string) int {
+	var skipNetwork bool
+	fs := newCommandFlagSet("doctor", "[flags]", "Diagnose common problems with the go2v installation, the active toolchain and PATH setup.")
+	registerRootFlag(fs)
+	fs.BoolVar(&skipNetwork, "skip-network", false, "Do not check connectivity to go.dev.")
+	registerNetworkFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	r := &doctorReport{}
+	fmt.Printf("go2v %s (%s/%s)\n\n", version, runtime.GOOS, runtime.GOARCH)
+
+	// 平台检测
+	goArch, err := detectGoArch(false)
+	if err != nil {
+		r.fail("Platform detection: %v", err)
+	} else {
+		r.ok("Platform: %s/%s", runtime.GOOS, goArch)
+	}
+
+	homeDir, store, globalInstall, err := openToolchainStore()
+	if err != nil {
+		r.fail("%v", err)
+		return 1
+	}
+
+	// 工具链目录
+	if fi, err := os.Stat(store.Root); os.IsNotExist(err) {
+		r.warn("Toolchain directory %s does not exist yet (run 'go2v install')", store.Root)
+	} else if err != nil {
+		r.fail("Toolchain directory %s: %v", store.Root, err)
+	} else if !fi.IsDir() {
+		r.fail("Toolchain directory %s is not a directory", store.Root)
+	} else if err := checkDirWritable(store.Root); err != nil {
+		r.fail("Toolchain directory %s is not writable: %v", store.Root, err)
+	} else {
+		r.ok("Toolchain directory: %s", store.Root)
+	}
+
+	// 已安装版本
+	installed, err := store.Installed()
+	if err != nil {
+		r.fail("Failed to read %s: %v", store.VersionsDir(), err)
+	} else if len(installed) == 0 {
+		r.warn("No Go versions installed")
+	} else {
+		r.ok("Installed versions: %s", strings.Join(installed, ", "))
+		for _, v := range installed {
+			if err := verifyGoRoot(store.VersionPath(v), v); err != nil {
+				r.warn("go%s looks incomplete: %v (reinstall with 'go2v install %s')", v, err, v)
+			}
+		}
+	}
+
+	// 中断安装遗留的目录
+	if leftovers, _ := filepath.Glob(filepath.Join(store.VersionsDir(), stagingDirPrefix+"*")); len(leftovers) > 0 {
+		r.warn("Leftover staging directories from interrupted installs: %s", strings.Join(leftovers, ", "))
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(store.VersionsDir(), "*"+backupDirSuffix)); len(leftovers) > 0 {
+		r.warn("Leftover backup directories from interrupted installs: %s", strings.Join(leftovers, ", "))
+	}
+
+	// 当前激活版本
+	active, err := store.Active()
+	activeOK := false
+	if err != nil {
+		r.fail("Failed to read %s: %v", store.CurrentLink(), err)
+	} else if active == "" {
+		r.warn("No active Go version (%s does not exist)", store.CurrentLink())
+	} else if !store.IsInstalled(active) {
+		r.fail("%s points to go%s, which is not installed", store.CurrentLink(), active)
+	} else if err := verifyGoRoot(store.VersionPath(active), active); err != nil {
+		r.fail("Active version go%s is incomplete: %v", active, err)
+	} else {
+		r.ok("Active version: go%s", active)
+		activeOK = true
+	}
+
+	// PATH 配置
+	goBinPath := filepath.Join(store.CurrentLink(), "bin")
+	if pathListContains(os.Getenv("PATH"), goBinPath) {
+		r.ok("PATH contains %s", goBinPath)
+	} else {
+		r.warn("PATH in this shell does not contain %s (open a new shell or run: eval \"$(go2v env)\")", goBinPath)
+	}
+
+	profilePath := filepath.Join(homeDir, ".profile")
+	if globalInstall {
+		profilePath = filepath.Join(systemProfileDDirextory, systemGoProfileFilename)
+	}
+	if content, err := os.ReadFile(profilePath); err != nil {
+		r.warn("Cannot read %s: %v", profilePath, err)
+	} else if strings.Contains(string(content), goBinPath) {
+		r.ok("%s adds %s to PATH", profilePath, goBinPath)
+	} else {
+		r.warn("%s does not add %s to PATH (run 'go2v use <version>' to configure it)", profilePath, goBinPath)
+	}
+
+	// PATH 中实际生效的 go 命令
+	if goCmd, err := exec.LookPath("go"); err != nil {
+		r.warn("No 'go' command found in PATH")
+	} else if activeOK {
+		resolved, err := filepath.EvalSymlinks(goCmd)
+		activeRoot, rootErr := filepath.EvalSymlinks(store.VersionPath(active))
+		if err == nil && rootErr == nil && isWithinDir(activeRoot, resolved) {
+			r.ok("'go' in PATH resolves to the active toolchain (%s)", goCmd)
+		} else {
+			r.warn("'go' in PATH is %s, which is not the active toolchain go%s", goCmd, active)
+		}
+	}
+
+	// 旧版本 go2v 的单版本安装
+	if _, err := os.Stat(filepath.Join(homeDir, legacyUserInstallDir)); err == nil {
+		r.warn("Legacy installation at %s is no longer managed by go2v", filepath.Join(homeDir, legacyUserInstallDir))
+	}
+
+	// 网络连通性
+	if !skipNetwork {
+		resp, err := httpClient.Get(latestVersionTextURL)
+		if err != nil {
+			r.fail("Cannot reach %s: %v", latestVersionTextURL, err)
+		} else {
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				r.fail("%s returned status code %d", latestVersionTextURL, resp.StatusCode)
+			} else {
+				r.ok("Network: %s is reachable", latestVersionTextURL)
+			}
+		}
+	}
+
+	fmt.Printf("\n%d problem(s), %d warning(s)\n", r.failures, r.warnings)
+	if r.failures > 0 {
+		return 1
+	}
+	return 0
+}
+
+// checkDirWritable 通过创建临时文件检查目录是否可写
+func checkDirWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".go2v-write-test-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
+
+// pathListContains 判断 PATH 风格的路径列表中是否包含 dir
+func pathListContains(pathList, dir string) bool {
+	for _, p := range filepath.SplitList(pathList) {
+		if filepath.Clean(p) == filepath.Clean(dir) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// runEnv 实现 "go2v env" 子命令：输出当前激活版本的 shell 环境变量设置
+func runEnv(args []string) int {
+	fs := newCommandFlagSet("env", "[flags]", "Print shell commands that put the active Go toolchain on PATH.\nUse it as: eval \"$(go2v env)\"")
+	registerRootFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	_, store, _, err := openToolchainStore()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	active, err := store.Active()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to read active toolchain: %v\n", err)
+		return 1
+	}
+	if active == "" {
+		fmt.Fprintf(os.Stderr, "Error: No active Go version. Install one with 'go2v install' first.\n")
+		return 1
+	}
+
+	// 输出到 stdout 的内容必须是合法的 shell 语句
+	fmt.Printf("# go2v: active Go version go%s\n", active)
+	fmt.Printf("export GO2V_ROOT=%s\n", shellQuote(store.Root))
+	fmt.Printf("export GO2V_GO_VERSION=%s\n", shellQuote("go"+active))
+	fmt.Printf("export PATH=%s:\"$PATH\"\n", shellQuote(filepath.Join(store.CurrentLink(), "bin")))
+	return 0
+}
+
+// shellQuote 将 s 转为 POSIX shell 的单引号字符串，其中的 $、` 和 \ 不会被展开
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
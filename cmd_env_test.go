@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no POSIX shell available")
+	}
+	for _, s := range []string{
+		"/home/user/.local/go2v",
+		"/home/o'brien/go2v",
+		"/tmp/$HOME/`id`/$(id)/\\n/\"quoted\"",
+		"",
+	} {
+		out, err := exec.Command(sh, "-c", "printf '%s' "+shellQuote(s)).Output()
+		if err != nil {
+			t.Fatalf("sh -c with %q: %v", s, err)
+		}
+		if string(out) != s {
+			t.Errorf("shellQuote(%q) evaluated to %q", s, out)
+		}
+	}
+}
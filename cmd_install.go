@@ -0,0 +1,492 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// registerInstallFlags 注册安装相关的 flag，供 install 和 update 子命令共用
+func registerInstallFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&insecureSkipVerify, "insecure-skip-verify", false, "Install even if no SHA-256 checksum is available for the archive (NOT recommended).")
+	fs.BoolVar(&verifySignature, "verify-signature", false, "Verify the archive's OpenPGP signature (<archive>.asc) in addition to its SHA-256 checksum.")
+	fs.StringVar(&gpgKeyringPath, "gpg-keyring", "", "Path to an OpenPGP public keyring used with --verify-signature. Required unless this build embeds the Go release signing key; revoked, expired and improperly bound keys are ignored.")
+	fs.Var(&mirrorSpecs, "mirror", "Download archives from a mirror first: golang.google.cn, aliyun, ustc or a base URL. Can be specified multiple times; mirrors are tried in order, then the release source. Checksums always come from the release source (default: $GO2V_MIRROR or 'mirror' lines in the config file).")
+	fs.BoolVar(&noMirrorProbe, "no-mirror-probe", false, "Use mirrors in the configured order instead of probing them and trying the fastest first.")
+	fs.IntVar(&downloadSegments, "download-segments", 1, "Download the archive as this many byte ranges over parallel connections, which helps on high-latency links (1 = single stream). Falls back to a single stream if the server does not support range requests.")
+	fs.IntVar(&extractWorkers, "extract-workers", 1, "Number of parallel file writers used when extracting the archive (1 = sequential).")
+}
+
+// runInstall 实现 "go2v install" 子命令，也是不带子命令时的默认行为
+func runInstall(args []string) int {
+	var fromMod bool
+	fs := newCommandFlagSet("install", "[flags] [version...]\n       go2v install --from-mod [dir]", "Download, verify and install a Go toolchain, then make it the active one.\nWithout a version, the nearest .go-version, .tool-versions or go.mod in the current\ndirectory or its parents decides; otherwise the latest stable release is installed.")
+	fs.Var(&targetVersions, "v", "Specify the Go version to install: a version (1.22.2), a minor line (1.23, newest patch), a constraint (\">=1.21 <1.23\", ~1.22, ^1.21) or stable/oldstable/latest. Can be specified multiple times.")
+	fs.BoolVar(&allowUnstable, "unstable", false, "Allow release candidates and betas when resolving minor lines, constraints and 'latest' (without a version, installs the newest release of any kind).")
+	fs.BoolVar(&fromMod, "from-mod", false, "Install the version required by go.mod in [dir] or its parents (toolchain directive first, then go directive).")
+	registerRootFlag(fs)
+	registerInstallFlags(fs)
+	registerMetadataFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if fromMod {
+		if len(targetVersions) > 0 || fs.NArg() > 1 {
+			fmt.Fprintf(os.Stderr, "Error: --from-mod cannot be combined with explicit versions\n")
+			return 2
+		}
+		dir := "."
+		if fs.NArg() == 1 {
+			dir = fs.Arg(0)
+		}
+		src, found, err := versionFromGoMod(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to read go.mod: %v\n", err)
+			return 1
+		}
+		if !found {
+			fmt.Fprintf(os.Stderr, "Error: No go.mod found in %s or its parent directories\n", dir)
+			return 1
+		}
+		fmt.Printf("Using Go version %s\n", src)
+		targetVersions = listArgs{src.Spec}
+		return doInstall()
+	}
+
+	// 也接受位置参数形式的版本号，例如 "go2v install 1.22.5"
+	targetVersions = append(targetVersions, fs.Args()...)
+
+	// 未指定版本时，使用最近的 .go-version / .tool-versions 或 go.mod 中声明的版本
+	if len(targetVersions) == 0 {
+		src, found, err := defaultVersionSource(".")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Ignoring version file: %v\n", err)
+		} else if found {
+			fmt.Printf("Using Go version %s\n", src)
+			fmt.Println("Pass -v stable to install the latest stable release instead.")
+			targetVersions = listArgs{src.Spec}
+		}
+	}
+	return doInstall()
+}
+
+// doInstall 按 targetVersions 等全局选项执行一次安装
+func doInstall() int {
+	debugPrint("Debug mode enabled")
+
+	fmt.Println("Starting GO environment installation (rootless by default)")
+
+	// 获取系统信息（内核版本和架构）
+	goArch, err := detectGoArch(true)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	// store 多版本工具链存储（用户主目录下的 .local/go2v，--root 模式下为 /usr/local/go2v）
+	homeDir, store, globalInstall, err := openToolchainStore()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Toolchain directory set to: %s\n", store.Root)
+
+	// source 版本信息和安装包的来源 (--source，默认为 go.dev)
+	source, err := releaseSource()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	debugPrint("Using release sources: %s", source.Name())
+	mirrors, err := configuredMirrors()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	// 获取所有 Go 版本信息列表（从 JSON API）
+	// 需要预发布版本时直接获取包含全部版本的列表，否则先获取当前受支持的版本
+	includeAll := allowUnstable
+	for _, targetVer := range targetVersions {
+		if v, err := parseGoVersion(targetVer); err == nil && v.PreKind != "" {
+			includeAll = true
+		}
+	}
+	debugPrint("Fetching all Go version information from JSON API (include all: %v)...", includeAll)
+	allVersions, err := getAllGoVersions(includeAll)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to get Go version list from JSON API: %v\n", err)
+		// 离线时没有元数据就无法确定版本和校验和
+		if offlineMode {
+			return 1
+		}
+	}
+	if allVersions != nil {
+		debugPrint("Fetched %d Go versions from JSON API", len(allVersions))
+	}
+
+	// versionToInstall 最终确定的版本号
+	// archiveName 最终确定的归档文件名
+	// expectedChecksum 下载文件应有的 SHA-256 校验和 (来自 JSON API)
+	// archiveSize 归档大小 (来自 JSON API，未知时为 0)
+	var versionToInstall, archiveName, expectedChecksum string
+	var archiveSize int64
+	foundDownloadable := false
+
+	// 没有 JSON API 数据时，stable/latest 与未指定版本相同，走纯文本接口回退
+	if allVersions == nil && len(targetVersions) > 0 && (targetVersions[0] == versionKeywordStable || targetVersions[0] == versionKeywordLatest) {
+		debugPrint("Treating %q as latest stable version without JSON API", targetVersions[0])
+		targetVersions = nil
+	}
+
+	if len(targetVersions) > 0 {
+		debugPrint("Target versions specified: %v", targetVersions)
+		for _, targetVer := range targetVersions {
+			// 在 JSON API 数据中解析版本：完整版本号精确匹配，版本线 (例如 1.22) 解析为最新补丁版本，
+			// 约束表达式和 stable/oldstable/latest 选择满足条件的最高版本
+			if allVersions != nil {
+				v, file, err := resolveVersion(targetVer, allVersions, runtime.GOOS, goArch, allowUnstable)
+				// 当前版本列表中没有时，再到包含已归档旧版本和预发布版本的完整列表中查找
+				if err != nil && !includeAll {
+					fmt.Printf("Version %s not found among current releases, searching archived and unstable releases...\n", targetVer)
+					if archived, archivedErr := getAllGoVersions(true); archivedErr == nil {
+						allVersions, includeAll = archived, true
+						v, file, err = resolveVersion(targetVer, allVersions, runtime.GOOS, goArch, allowUnstable)
+					} else {
+						debugPrint("Failed to fetch full version list: %v", archivedErr)
+					}
+				}
+				if err == nil {
+					versionToInstall = strings.TrimPrefix(v.Version, "go")
+					archiveName = file.Filename
+					expectedChecksum = file.Checksum
+					archiveSize = int64(file.Size)
+					foundDownloadable = true
+					fmt.Printf("Resolved version %s to go%s\n", targetVer, versionToInstall)
+					debugPrint("Found matching download file for %s/%s: %s (sha256: %s)", runtime.GOOS, goArch, file.Filename, file.Checksum)
+					break
+				}
+				debugPrint("Version resolution failed: %v", err)
+			}
+
+			// 约束表达式和关键字只能依靠 JSON API 解析
+			spec, err := parseGoVersion(targetVer)
+			if err != nil {
+				if allVersions == nil {
+					fmt.Fprintf(os.Stderr, "Error: Cannot resolve %q without the Go version list from JSON API\n", targetVer)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: No Go release matching %q found for %s/%s\n", targetVer, runtime.GOOS, goArch)
+				}
+				return 1
+			}
+
+			if offlineMode {
+				fmt.Fprintf(os.Stderr, "Error: go%s (%s/%s) is not in the cached release metadata. Run without --offline to look it up.\n", spec, runtime.GOOS, goArch)
+				return 1
+			}
+
+			// JSON API 中没有时按官方命名规则构造 URL (Go 1.21 之前的首个正式版没有 .0 后缀)
+			fmt.Printf("Warning: Could not find specified version %s (%s/%s) in JSON API. Attempting to construct URL...\n", targetVer, runtime.GOOS, goArch)
+			versionToInstall = spec.String()
+			if spec.IsMinorOnly() {
+				fmt.Printf("Warning: Cannot determine the latest patch release of %s without the JSON API, using go%s\n", targetVer, versionToInstall)
+			}
+			archiveName = fmt.Sprintf("go%s.%s-%s.tar.gz", versionToInstall, runtime.GOOS, goArch)
+			fmt.Printf("Attempting to construct archive name: %s\n", archiveName)
+			foundDownloadable = true
+			break
+		}
+
+		if !foundDownloadable {
+			fmt.Fprintf(os.Stderr, "Error: No matching Go version found for installation. Please check the version number and system architecture.\n")
+			return 1
+		}
+
+	} else {
+		// 未指定版本，查找最新稳定版本
+		debugPrint("No target version specified")
+
+		// 优先从 JSON API 获取最新稳定版本
+		if allVersions != nil {
+			keyword := versionKeywordStable
+			if allowUnstable {
+				keyword = versionKeywordLatest
+			}
+			debugPrint("Looking for %s version in JSON API", keyword)
+			if v, file, err := resolveVersion(keyword, allVersions, runtime.GOOS, goArch, allowUnstable); err == nil {
+				versionToInstall = strings.TrimPrefix(v.Version, "go")
+				archiveName = file.Filename
+				expectedChecksum = file.Checksum
+				archiveSize = int64(file.Size)
+				foundDownloadable = true
+				debugPrint("Found latest stable download file for %s/%s: %s (sha256: %s)", runtime.GOOS, goArch, file.Filename, file.Checksum)
+			} else {
+				debugPrint("Latest stable version resolution failed: %v", err)
+			}
+		}
+
+		// 如果 JSON API 没找到，尝试从文本接口获取最新版本号
+		if (!foundDownloadable || archiveName == "") && offlineMode {
+			fmt.Fprintf(os.Stderr, "Error: No release for %s/%s found in the cached release metadata.\n", runtime.GOOS, goArch)
+			return 1
+		}
+		if !foundDownloadable || archiveName == "" {
+			fmt.Printf("Warning: Could not find latest stable version in JSON API. Attempting to get latest version from %s...\n", source.Name())
+			latestVer, err := source.LatestVersion()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Failed to get latest Go version: %v\n", err)
+				fmt.Fprintf(os.Stderr, "Error: Could not determine Go version to install.\n")
+				return 1
+			}
+			versionToInstall = latestVer
+			archiveName = fmt.Sprintf("go%s.%s-%s.tar.gz", versionToInstall, runtime.GOOS, goArch)
+			fmt.Printf("Deduced latest version: %s, Constructed archive name: %s\n", versionToInstall, archiveName)
+			foundDownloadable = true
+		}
+
+		if !foundDownloadable {
+			fmt.Fprintf(os.Stderr, "Error: Internal error: Failed to determine version and download URL.\n")
+			return 1
+		}
+		if allowUnstable {
+			fmt.Printf("No version specified, installing latest version (including unstable): %s\n", versionToInstall)
+		} else {
+			fmt.Printf("No version specified, installing latest stable version: %s\n", versionToInstall)
+		}
+	}
+
+	// 配置了多个镜像时测速，最快的镜像优先 (离线时不使用镜像，无需测速)
+	rankedMirrors := len(mirrors) > 1 && !noMirrorProbe && !offlineMode
+	if rankedMirrors {
+		mirrors = rankMirrors(mirrors, archiveName, archiveSize)
+	}
+
+	// downloadURLs 镜像和各来源提供的下载地址，按顺序尝试
+	downloadURLs := candidateDownloadURLs(mirrors, source, archiveName)
+	if len(downloadURLs) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: None of the configured sources (%s) provides %s\n", source.Name(), archiveName)
+		return 1
+	}
+	downloadURL := downloadURLs[0]
+	fmt.Printf("Confirmed download URL: %s\n", downloadURL)
+
+	// JSON API 中没有校验和时 (手动构造的文件名)，尝试获取来源提供的 .sha256 校验文件
+	if expectedChecksum == "" {
+		debugPrint("No checksum from JSON API, fetching checksum sidecar for %s", archiveName)
+		sidecarChecksum, err := source.FetchChecksum(archiveName)
+		if err != nil {
+			if !insecureSkipVerify {
+				fmt.Fprintf(os.Stderr, "Error: No checksum available for %s: not found in JSON API and failed to fetch its .sha256 file: %v\n", archiveName, err)
+				fmt.Fprintf(os.Stderr, "Error: Refusing to install an unverified archive. Use --insecure-skip-verify to override.\n")
+				return 1
+			}
+			fmt.Fprintf(os.Stderr, "Warning: Failed to fetch checksum sidecar: %v\n", err)
+			fmt.Println("Warning: --insecure-skip-verify set, integrity of the download will NOT be verified")
+		} else {
+			expectedChecksum = sidecarChecksum
+			debugPrint("Got checksum from sidecar: %s", expectedChecksum)
+		}
+	}
+	if expectedChecksum != "" {
+		fmt.Printf("Expected SHA-256 checksum: %s\n", expectedChecksum)
+	}
+
+	// 安装包下载到缓存目录中并在安装后保留，供之后的重新安装和 --offline 使用
+	// 缓存目录不可用时回退到临时目录，安装后删除
+	downloadFileName := filepath.Base(archiveName)
+	if downloadFileName == "." || downloadFileName == "" || downloadFileName != archiveName {
+		fmt.Fprintf(os.Stderr, "Error: Invalid archive name: %q\n", archiveName)
+		return 1
+	}
+	keepArchive := true
+	downloadDir, err := archiveCacheDir()
+	if err == nil {
+		err = os.MkdirAll(downloadDir, 0755)
+	}
+	if err == nil {
+		err = checkDirWritable(downloadDir)
+	}
+	if err != nil {
+		if offlineMode {
+			fmt.Fprintf(os.Stderr, "Error: Archive cache is not available: %v\n", err)
+			return 1
+		}
+		debugPrint("Archive cache not available (%v), downloading to temporary directory", err)
+		downloadDir, keepArchive = os.TempDir(), false
+		if err := checkDirWritable(downloadDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Temporary directory %s is not writable: %v\n", downloadDir, err)
+			return 1
+		}
+	}
+	downloadFilePath := filepath.Join(downloadDir, downloadFileName)
+	debugPrint("Download file path: %s", downloadFilePath)
+
+	// 在下载前加载公钥环，避免下载完成后才发现无法验证
+	var keyring []*pgpPublicKey
+	if verifySignature {
+		keyring, err = loadKeyring(gpgKeyringPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to load OpenPGP keyring: %v\n", err)
+			return 1
+		}
+		debugPrint("Loaded %d OpenPGP public keys", len(keyring))
+	}
+
+	// 缓存中已有校验和匹配的归档时直接使用，否则依次从各来源下载 (离线时只使用本地目录来源)
+	if reuseCachedArchive(downloadFilePath, expectedChecksum) {
+		fmt.Printf("Using cached installation package: %s\n", downloadFilePath)
+	} else {
+		downloaded := false
+		for _, u := range downloadURLs {
+			if offlineMode && !isLocalURL(u) {
+				continue
+			}
+			fmt.Printf("Downloading installation package from %s...\n", u)
+			if err = downloadFileWithRetry(u, downloadFilePath, expectedChecksum); err == nil {
+				downloadURL, downloaded = u, true
+				break
+			}
+			fmt.Fprintf(os.Stderr, "Warning: Download from %s failed: %v\n", u, err)
+			// 测速选出的镜像下载失败，说明排名已不可靠，下次运行时重新测速
+			if rankedMirrors && u == downloadURLs[0] {
+				forgetMirrorRanking()
+			}
+		}
+		if !downloaded {
+			if offlineMode {
+				fmt.Fprintf(os.Stderr, "Error: %s is not in the local archive cache %s (--offline)\n", downloadFileName, downloadDir)
+			} else {
+				fmt.Fprintf(os.Stderr, "Error: Failed to download installation package from any source\n")
+			}
+			return 1
+		}
+		fmt.Printf("Installation package downloaded successfully: %s\n", downloadFilePath)
+	}
+
+	// 验证 OpenPGP 签名
+	if verifySignature {
+		fmt.Println("Verifying OpenPGP signature...")
+		signature, err := loadSignature(append([]string{downloadURL}, downloadURLs...), downloadFilePath, keepArchive)
+		if err == nil {
+			var signer *pgpPublicKey
+			signer, err = verifyDetachedSignature(downloadFilePath, signature, keyring)
+			if err == nil {
+				fmt.Printf("Good signature from key %s\n", signer.Fingerprint)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: OpenPGP signature verification failed: %v\n", err)
+			os.Remove(downloadFilePath)
+			os.Remove(downloadFilePath + ".asc")
+			return 1
+		}
+	}
+
+	// 每个版本安装到独立目录，例如 ~/.local/go2v/versions/go1.22.5
+	installPath := store.VersionPath(versionToInstall)
+	debugPrint("Version installation path: %s", installPath)
+
+	// 解压到暂存目录并原子替换旧的 Go 安装目录，失败时保留旧版本
+	fmt.Printf("Installing to %s...\n", installPath)
+	err = installGoRoot(downloadFilePath, installPath, versionToInstall)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: Installation failed, the previous installation (if any) has been kept.\n")
+		return 1
+	}
+
+	// 将 current 链接切换到新安装的版本
+	if err := store.Activate(versionToInstall); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to activate go%s: %v\n", versionToInstall, err)
+		return 1
+	}
+	fmt.Printf("Active toolchain: %s -> %s\n", store.CurrentLink(), installPath)
+
+	// 清理下载的 Go 安装包文件 (缓存目录中的归档保留)
+	if keepArchive {
+		fmt.Printf("Installation package kept in cache: %s\n", downloadFilePath)
+	} else {
+		fmt.Printf("Cleaning up downloaded installation package...\n")
+		debugPrint("Removing downloaded file: %s", downloadFilePath)
+		err = os.Remove(downloadFilePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to clean up installation package: %v\n", err)
+		} else {
+			fmt.Printf("Installation package cleaned up\n")
+		}
+	}
+
+	// 配置 PATH 环境变量，指向 current 链接而非具体版本，切换版本时无需修改 PATH
+	configurePath(homeDir, store.CurrentLink(), globalInstall)
+
+	// 旧版本 go2v 的单版本安装目录不再被使用
+	legacyPath := filepath.Join(homeDir, legacyUserInstallDir)
+	if !globalInstall {
+		if _, err := os.Stat(legacyPath); err == nil {
+			fmt.Printf("\nNote: A previous single-version installation exists at %s. It is no longer managed by go2v and can be removed once the new toolchain works.\n", legacyPath)
+		}
+	}
+
+	// 最终安装成功提示
+	fmt.Println("\nGo environment installation complete")
+	fmt.Printf("Installed version: %s\n", versionToInstall)
+	return 0
+}
+
+// reuseCachedArchive 判断缓存中的归档是否可以直接使用
+// 有校验和时必须匹配，不匹配的文件会被删除；没有校验和 (--insecure-skip-verify) 时只在离线模式下使用
+func reuseCachedArchive(path, expectedChecksum string) bool {
+	if _, err := os.Stat(path); err != nil {
+		return false
+	}
+	if expectedChecksum == "" {
+		if offlineMode {
+			fmt.Println("Warning: --insecure-skip-verify set, using cached archive without checksum verification")
+		}
+		return offlineMode
+	}
+
+	actual, err := fileSHA256(path)
+	if err != nil {
+		debugPrint("Failed to hash cached archive %s: %v", path, err)
+		return false
+	}
+	if !strings.EqualFold(actual, expectedChecksum) {
+		fmt.Printf("Warning: Cached archive %s has sha256 %s, expected %s. Discarding it.\n", path, actual, expectedChecksum)
+		os.Remove(path)
+		return false
+	}
+	fmt.Printf("Checksum verified: sha256 %s\n", actual)
+	return true
+}
+
+// loadSignature 返回归档的 OpenPGP 签名，优先使用缓存在归档旁的 .asc 文件，否则依次从 archiveURLs 旁获取
+// 从网络获取的签名在 keep 为 true 时保存到缓存中，供 --offline 使用
+func loadSignature(archiveURLs []string, archivePath string, keep bool) ([]byte, error) {
+	sigPath := archivePath + ".asc"
+	if data, err := os.ReadFile(sigPath); err == nil {
+		debugPrint("Using cached signature %s", sigPath)
+		return data, nil
+	}
+	var signature []byte
+	err := fmt.Errorf("no download URL")
+	for _, u := range archiveURLs {
+		if signature, err = fetchSignature(u); err == nil {
+			break
+		}
+		debugPrint("Failed to fetch signature for %s: %v", u, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if keep {
+		if err := os.WriteFile(sigPath, signature, 0644); err != nil {
+			debugPrint("Failed to cache signature %s: %v", sigPath, err)
+		}
+	}
+	return signature, nil
+}
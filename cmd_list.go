@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// runList 实现 "go2v list" 子命令：列出已安装的版本并标记当前激活的版本
+func runList(args []string) int {
+	fs := newCommandFlagSet("list", "[flags]", "List the Go versions installed by go2v. The active version is marked with '*'.")
+	registerRootFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	_, store, _, err := openToolchainStore()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	installed, err := store.Installed()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to read %s: %v\n", store.VersionsDir(), err)
+		return 1
+	}
+	if len(installed) == 0 {
+		fmt.Printf("No Go versions installed in %s\n", store.VersionsDir())
+		return 0
+	}
+
+	active, err := store.Active()
+	if err != nil {
+		debugPrint("Failed to read active toolchain: %v", err)
+	}
+	for _, v := range installed {
+		if v == active {
+			fmt.Printf("* go%s (active)\n", v)
+		} else {
+			fmt.Printf("  go%s\n", v)
+		}
+	}
+	return 0
+}
+
+// remoteRelease list-remote --json 输出的单个版本
+type remoteRelease struct {
+	Version   string `json:"version"`
+	Stable    bool   `json:"stable"`
+	Installed bool   `json:"installed"`
+	Active    bool   `json:"active"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	Filename  string `json:"filename"`
+	URL       string `json:"url,omitempty"`
+	Size      int    `json:"size"`
+	SHA256    string `json:"sha256"`
+}
+
+// runListRemote 实现 "go2v list-remote" 子命令：列出可供下载的版本，并标记已安装和当前激活的版本
+func runListRemote(args []string) int {
+	var showAll, showArchived, unstableOnly, jsonOutput bool
+	var line, goos, goArch string
+	fs := newCommandFlagSet("list-remote", "[flags]", "List Go releases available for download, newest first.\nBy default only stable releases for this platform are shown; installed versions are marked,\nand the active one is marked with '*'.")
+	fs.BoolVar(&showAll, "all", false, "Include unstable releases (release candidates and betas); implies --archived.")
+	fs.BoolVar(&unstableOnly, "unstable-only", false, "Show only unstable releases (release candidates and betas); implies --archived.")
+	fs.BoolVar(&showArchived, "archived", false, "Include archived releases that are no longer supported.")
+	fs.StringVar(&line, "line", "", "Show only releases of a minor line, e.g. 1.22 (includes archived releases).")
+	fs.StringVar(&goos, "os", runtime.GOOS, "Show releases with an archive for this GOOS.")
+	fs.StringVar(&goArch, "arch", "", "Show releases with an archive for this GOARCH (default: detected architecture).")
+	fs.BoolVar(&jsonOutput, "json", false, "Print the releases as a JSON array.")
+	registerMetadataFlags(fs)
+	registerRootFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	if showAll && unstableOnly {
+		fmt.Fprintf(os.Stderr, "Error: --all and --unstable-only cannot be combined\n")
+		return 2
+	}
+
+	var lineVer goVersion
+	if line != "" {
+		v, err := parseGoVersion(line)
+		if err != nil || !v.IsMinorOnly() {
+			fmt.Fprintf(os.Stderr, "Error: --line expects a minor line such as 1.22, got %q\n", line)
+			return 2
+		}
+		lineVer = v
+	}
+
+	localArch, err := detectGoArch(false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if goArch == "" {
+		goArch = localArch
+	}
+
+	// 已安装的版本只对应本机平台，查看其他平台时不做标记
+	installed := map[string]bool{}
+	active := ""
+	if goos == runtime.GOOS && goArch == localArch {
+		if _, store, _, err := openToolchainStore(); err != nil {
+			debugPrint("Not marking installed versions: %v", err)
+		} else {
+			versions, err := store.Installed()
+			if err != nil {
+				debugPrint("Failed to read installed versions: %v", err)
+			}
+			for _, v := range versions {
+				installed[v] = true
+			}
+			if active, err = store.Active(); err != nil {
+				debugPrint("Failed to read active toolchain: %v", err)
+			}
+		}
+	}
+
+	source, err := releaseSource()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	allVersions, err := source.ListReleases(showAll || showArchived || unstableOnly || line != "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to get Go version list from JSON API: %v\n", err)
+		return 1
+	}
+	sortGoVersionInfos(allVersions)
+
+	releases := []remoteRelease{}
+	for _, v := range allVersions {
+		switch {
+		case unstableOnly && v.Stable:
+			continue
+		case !v.Stable && !showAll && !unstableOnly:
+			continue
+		}
+		name := strings.TrimPrefix(v.Version, "go")
+		if line != "" {
+			have, err := parseGoVersion(name)
+			if err != nil || !have.sameLine(lineVer) {
+				continue
+			}
+		}
+		file, ok := v.archiveFor(goos, goArch)
+		if !ok {
+			debugPrint("Skipping %s: no archive for %s/%s", v.Version, goos, goArch)
+			continue
+		}
+		releases = append(releases, remoteRelease{
+			Version:   name,
+			Stable:    v.Stable,
+			Installed: installed[name],
+			Active:    name == active && installed[name],
+			OS:        goos,
+			Arch:      goArch,
+			Filename:  file.Filename,
+			URL:       archiveURL(source, file.Filename),
+			Size:      file.Size,
+			SHA256:    file.Checksum,
+		})
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(releases); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to write JSON: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if len(releases) == 0 {
+		fmt.Fprintf(os.Stderr, "No matching releases found for %s/%s\n", goos, goArch)
+		return 0
+	}
+	for _, r := range releases {
+		var notes []string
+		if !r.Stable {
+			notes = append(notes, "unstable")
+		}
+		switch {
+		case r.Active:
+			notes = append(notes, "active")
+		case r.Installed:
+			notes = append(notes, "installed")
+		}
+		mark := "  "
+		if r.Active {
+			mark = "* "
+		}
+		if len(notes) > 0 {
+			fmt.Printf("%s%s (%s)\n", mark, r.Version, strings.Join(notes, ", "))
+		} else {
+			fmt.Printf("%s%s\n", mark, r.Version)
+		}
+	}
+	return 0
+}
+
+// archiveURL 返回来源提供的归档下载地址，无法定位时返回空字符串
+func archiveURL(source Source, filename string) string {
+	u, err := source.ArchiveURL(filename)
+	if err != nil {
+		return ""
+	}
+	return u
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	// selfVersionURL go2v 最新版本号，与 install.sh 使用的地址一致
+	selfVersionURL = "https://raw.githubusercontent.com/WJQSERVER/go2v/main/VERSION"
+	// selfDownloadURLFormat go2v 发布二进制的下载地址 (版本号, GOOS, GOARCH)
+	selfDownloadURLFormat = "https://github.com/WJQSERVER/go2v/releases/download/%s/go2v-%s-%s"
+)
+
+// runSelfUpdate 实现 "go2v self-update" 子命令：下载最新发布的 go2v 并替换当前可执行文件
+func runSelfUpdate(args []string) int {
+	var force bool
+	var checksum string
+	fs := newCommandFlagSet("self-update", "[flags]", "Replace this go2v binary with the latest release from GitHub.\nThe download is verified against the <asset>.sha256 file published with the release\n(or --sha256); without a checksum the binary is not replaced.")
+	fs.BoolVar(&force, "force", false, "Reinstall even if the current version is already the latest.")
+	fs.StringVar(&checksum, "sha256", "", "Expected SHA-256 of the release binary, for releases that do not publish a .sha256 file.")
+	registerNetworkFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	latest, err := getLatestSelfVersion()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to get latest go2v version: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Current go2v version: %s, latest release: %s\n", version, latest)
+	if latest == version && !force {
+		fmt.Println("go2v is already up to date")
+		return 0
+	}
+
+	exePath, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to locate the go2v executable: %v\n", err)
+		return 1
+	}
+	if resolved, err := filepath.EvalSymlinks(exePath); err == nil {
+		exePath = resolved
+	}
+	debugPrint("Current executable: %s", exePath)
+
+	// 临时文件与可执行文件位于同一目录，保证 rename 是原子的
+	tmpFile, err := os.CreateTemp(filepath.Dir(exePath), ".go2v-self-update-*")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Cannot write to %s: %v\n", filepath.Dir(exePath), err)
+		return 1
+	}
+	tmpPath := tmpFile.Name()
+	tmpFile.Close()
+	defer os.Remove(tmpPath)
+	defer discardPartialDownload(tmpPath + partFileSuffix)
+
+	downloadURL := fmt.Sprintf(selfDownloadURLFormat, latest, runtime.GOOS, runtime.GOARCH)
+	// 未经校验的二进制既不能运行也不能替换当前可执行文件
+	if checksum != "" {
+		if checksum, err = parseChecksumFile([]byte(checksum), "--sha256"); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	} else if checksum, err = fetchChecksumSidecar(downloadURL); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: No checksum available for %s: %v\n", downloadURL, err)
+		fmt.Fprintf(os.Stderr, "Refusing to replace %s with an unverified binary; pass the expected SHA-256 with --sha256 if you obtained it elsewhere\n", exePath)
+		return 1
+	}
+	fmt.Printf("Downloading %s...\n", downloadURL)
+	fmt.Printf("Expected SHA-256 checksum: %s\n", checksum)
+	if err := downloadFileWithRetry(downloadURL, tmpPath, checksum); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to download go2v %s: %v\n", latest, err)
+		return 1
+	}
+	if err := os.Chmod(tmpPath, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to make %s executable: %v\n", tmpPath, err)
+		return 1
+	}
+
+	// 替换前确认新二进制 (已通过校验) 可以在本机运行
+	if out, err := exec.Command(tmpPath, "help").CombinedOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Downloaded go2v binary does not run on this system: %v\n%s", err, out)
+		return 1
+	}
+
+	if err := os.Rename(tmpPath, exePath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to replace %s: %v\n", exePath, err)
+		return 1
+	}
+
+	// install.sh 会在二进制旁写入 .VERSION 文件，保持其同步
+	versionFile := exePath + ".VERSION"
+	if _, err := os.Stat(versionFile); err == nil {
+		if err := os.WriteFile(versionFile, []byte(latest), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to update %s: %v\n", versionFile, err)
+		}
+	}
+
+	fmt.Printf("go2v updated to %s (%s)\n", latest, exePath)
+	return 0
+}
+
+// getLatestSelfVersion 获取 go2v 最新发布的版本号
+func getLatestSelfVersion() (string, error) {
+	resp, err := httpClient.Get(selfVersionURL)
+	if err != nil {
+		return "", fmt.Errorf("unable to fetch %s: %w", selfVersionURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch %s, status code: %d", selfVersionURL, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", selfVersionURL, err)
+	}
+	latest := strings.TrimSpace(string(body))
+	if latest == "" || strings.ContainsAny(latest, "/ \n") {
+		return "", fmt.Errorf("unexpected version %q from %s", latest, selfVersionURL)
+	}
+	return latest, nil
+}
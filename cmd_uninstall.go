@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// runUninstall 实现 "go2v uninstall <version>" 子命令：删除已安装的版本
+func runUninstall(args []string) int {
+	var force bool
+	fs := newCommandFlagSet("uninstall", "[flags] <version>...", "Remove installed Go versions. The active version is only removed with --force.")
+	registerRootFlag(fs)
+	fs.BoolVar(&force, "force", false, "Also remove the active version (leaves no active toolchain).")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	_, store, _, err := openToolchainStore()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	active, err := store.Active()
+	if err != nil {
+		debugPrint("Failed to read active toolchain: %v", err)
+	}
+
+	exitCode := 0
+	for _, arg := range fs.Args() {
+		version := strings.TrimPrefix(arg, "go")
+		// 版本号会拼接成路径，拒绝包含路径分隔符的输入
+		if version == "" || strings.ContainsAny(version, `/\`) || strings.Contains(version, "..") {
+			fmt.Fprintf(os.Stderr, "Error: Invalid version %q\n", arg)
+			exitCode = 1
+			continue
+		}
+		if !store.IsInstalled(version) {
+			fmt.Fprintf(os.Stderr, "Error: go%s is not installed in %s\n", version, store.VersionsDir())
+			exitCode = 1
+			continue
+		}
+		if version == active && !force {
+			fmt.Fprintf(os.Stderr, "Error: go%s is the active toolchain. Switch to another version with 'go2v use' first, or pass --force.\n", version)
+			exitCode = 1
+			continue
+		}
+
+		fmt.Printf("Removing go%s from %s...\n", version, store.VersionPath(version))
+		if err := os.RemoveAll(store.VersionPath(version)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to remove go%s: %v\n", version, err)
+			exitCode = 1
+			continue
+		}
+		if version == active {
+			if err := os.Remove(store.CurrentLink()); err != nil && !os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "Warning: Failed to remove %s: %v\n", store.CurrentLink(), err)
+			}
+			fmt.Println("Warning: The active toolchain was removed, no Go version is active now")
+		}
+		fmt.Printf("Uninstalled go%s\n", version)
+	}
+	return exitCode
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// runUpdate 实现 "go2v update" 子命令：若有更新的稳定版本则安装并激活
+func runUpdate(args []string) int {
+	fs := newCommandFlagSet("update", "[flags]", "Install and activate the latest stable Go release if the active version is older.")
+	registerRootFlag(fs)
+	registerInstallFlags(fs)
+	registerMetadataFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	goArch, err := detectGoArch(false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	homeDir, store, globalInstall, err := openToolchainStore()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	allVersions, err := getAllGoVersions(false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to get Go version list from JSON API: %v\n", err)
+		return 1
+	}
+
+	latestInfo, _, err := resolveVersion(versionKeywordStable, allVersions, runtime.GOOS, goArch, false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: No stable Go release found for %s/%s: %v\n", runtime.GOOS, goArch, err)
+		return 1
+	}
+	latest := strings.TrimPrefix(latestInfo.Version, "go")
+
+	active, err := store.Active()
+	if err != nil {
+		debugPrint("Failed to read active toolchain: %v", err)
+	}
+	// 只在激活版本比最新稳定版旧时更新，不把 rc/beta 等更新的版本降级为稳定版
+	if activeVer, err := parseGoVersion(active); err == nil {
+		latestVer, _ := parseGoVersion(latest)
+		switch c := compareGoVersions(activeVer, latestVer); {
+		case c == 0:
+			fmt.Printf("go%s is already the latest stable version\n", latest)
+			return 0
+		case c > 0:
+			fmt.Printf("Active go%s is newer than the latest stable version go%s, nothing to update\n", active, latest)
+			return 0
+		}
+	}
+
+	// 已安装但未激活时只需切换
+	if store.IsInstalled(latest) {
+		if err := verifyGoRoot(store.VersionPath(latest), latest); err == nil {
+			if err := store.Activate(latest); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Failed to switch to go%s: %v\n", latest, err)
+				return 1
+			}
+			fmt.Printf("Switched active toolchain to already installed go%s\n", latest)
+			configurePath(homeDir, store.CurrentLink(), globalInstall)
+			return 0
+		}
+	}
+
+	if active != "" {
+		fmt.Printf("Updating from go%s to go%s\n", active, latest)
+	}
+	targetVersions = listArgs{latest}
+	return doInstall()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// runUse 实现 "go2v use [version]" 子命令：切换当前激活的工具链，不下载任何内容
+// 未指定版本时使用最近的 .go-version / .tool-versions 文件
+func runUse(args []string) int {
+	var allowPre bool
+	fs := newCommandFlagSet("use", "[flags] [version]", "Switch the active Go toolchain to an installed version without downloading anything.\nThe version may be a minor line (1.22) or a constraint, matched against installed versions.\nWithout a version, the nearest .go-version or .tool-versions file in the current directory\nor its parents is used.")
+	fs.BoolVar(&allowPre, "unstable", false, "Allow installed release candidates and betas to match minor lines and constraints.")
+	registerRootFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+
+	spec := ""
+	if fs.NArg() == 1 {
+		spec = strings.TrimPrefix(fs.Arg(0), "go")
+	} else {
+		src, found, err := findVersionFile(".")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to read version file: %v\n", err)
+			return 1
+		}
+		if !found {
+			fmt.Fprintf(os.Stderr, "Error: No version given and no %s or %s found in the current directory or its parents\n", goVersionFileName, toolVersionsFileName)
+			fs.Usage()
+			return 2
+		}
+		fmt.Printf("Using Go version %s\n", src)
+		spec = src.Spec
+	}
+
+	homeDir, store, globalInstall, err := openToolchainStore()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	installed, err := store.Installed()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to list installed versions: %v\n", err)
+		return 1
+	}
+	version, err := resolveInstalledVersion(spec, installed, allowPre)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v in %s\n", err, store.VersionsDir())
+		if len(installed) > 0 {
+			fmt.Fprintf(os.Stderr, "Installed versions: %s\n", strings.Join(installed, ", "))
+		}
+		fmt.Fprintf(os.Stderr, "Run 'go2v install %s' to install it first.\n", spec)
+		return 1
+	}
+	if version != spec {
+		debugPrint("Resolved %q to installed go%s", spec, version)
+	}
+
+	// 只切换到完整且版本匹配的安装，避免指向被中断或损坏的目录
+	if err := verifyGoRoot(store.VersionPath(version), version); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Installation of go%s is incomplete: %v\n", version, err)
+		fmt.Fprintf(os.Stderr, "Run 'go2v install %s' to reinstall it.\n", version)
+		return 1
+	}
+
+	previous, err := store.Active()
+	if err != nil {
+		debugPrint("Failed to read current active toolchain: %v", err)
+	}
+	if previous == version {
+		fmt.Printf("go%s is already the active toolchain\n", version)
+	} else {
+		if err := store.Activate(version); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to switch to go%s: %v\n", version, err)
+			return 1
+		}
+		if previous != "" {
+			fmt.Printf("Switched active toolchain from go%s to go%s\n", previous, version)
+		} else {
+			fmt.Printf("Activated go%s\n", version)
+		}
+	}
+
+	// PATH 指向 current 链接，这里只确保其已被配置
+	configurePath(homeDir, store.CurrentLink(), globalInstall)
+	return 0
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+// runWorkspace 实现 "go2v workspace [dir]" 子命令：扫描 go.work 和所有 go.mod，报告最高版本要求
+func runWorkspace(args []string) int {
+	var install, check bool
+	fs := newCommandFlagSet("workspace", "[flags] [dir]", "Scan dir (default: current directory) for go.work and every go.mod, report the highest\ngo/toolchain version required across all modules, and flag inconsistencies between them.")
+	fs.BoolVar(&install, "install", false, "Install the highest required version and make it the active one.")
+	fs.BoolVar(&check, "check", false, "Exit with status 1 if any inconsistency is found (useful in CI).")
+	registerRootFlag(fs)
+	registerInstallFlags(fs)
+	registerMetadataFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+	dir := "."
+	if fs.NArg() == 1 {
+		dir = fs.Arg(0)
+	}
+
+	report, err := scanWorkspace(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to scan %s: %v\n", dir, err)
+		return 1
+	}
+	if len(report.Files) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: No go.work or go.mod found under %s\n", report.Root)
+		return 1
+	}
+
+	fmt.Printf("Scanned %s\n\n", report.Root)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tGO\tTOOLCHAIN")
+	for _, f := range report.Files {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", f.Rel, orDash(f.Directives.Go), orDash(f.Directives.Toolchain))
+	}
+	w.Flush()
+	fmt.Println()
+
+	for _, issue := range report.Issues {
+		fmt.Printf("Warning: %s\n", issue)
+	}
+	if len(report.Issues) > 0 {
+		fmt.Println()
+	}
+
+	if !report.Found {
+		fmt.Fprintf(os.Stderr, "Error: None of the scanned files declares a go or toolchain version\n")
+		return 1
+	}
+	fmt.Printf("Highest required version: %s\n", report.Required)
+
+	if check && len(report.Issues) > 0 {
+		fmt.Fprintf(os.Stderr, "Error: Found %d inconsistencies\n", len(report.Issues))
+		return 1
+	}
+	if !install {
+		fmt.Printf("Run 'go2v workspace --install' to install it.\n")
+		return 0
+	}
+
+	fmt.Println()
+	targetVersions = listArgs{report.Required.Spec}
+	return doInstall()
+}
+
+// orDash 空字符串显示为 "-"
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// configFileEnv 覆盖配置文件路径的环境变量
+	configFileEnv = "GO2V_CONFIG"
+	// configFileName 配置文件名，位于用户配置目录下的 go2v 目录中
+	configFileName = "config"
+)
+
+// configFilePath 返回配置文件路径，可通过 GO2V_CONFIG 覆盖，默认为 ~/.config/go2v/config
+func configFilePath() (string, error) {
+	if path := os.Getenv(configFileEnv); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, toolchainsDirName, configFileName), nil
+}
+
+// parseConfig 解析 "key = value" 格式的配置，同一个 key 可以出现多次，"#" 开头的行为注释
+func parseConfig(data []byte) (map[string][]string, error) {
+	config := map[string][]string{}
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("line %d: expected \"key = value\"", lineNum)
+		}
+		config[key] = append(config[key], value)
+	}
+	return config, scanner.Err()
+}
+
+// configValues 返回配置文件中 key 的所有值，配置文件不存在或无法解析时返回 nil
+func configValues(key string) []string {
+	path, err := configFilePath()
+	if err != nil {
+		debugPrint("Config file disabled: %v", err)
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to read config file %s: %v\n", path, err)
+		}
+		return nil
+	}
+	config, err := parseConfig(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Ignoring config file %s: %v\n", path, err)
+		return nil
+	}
+	return config[key]
+}
+
+// settingValues 按 flag、环境变量 (逗号分隔)、配置文件的优先级返回多值设置
+func settingValues(flagValues []string, envName, configKey string) []string {
+	var values []string
+	switch {
+	case len(flagValues) > 0:
+		values = flagValues
+	case os.Getenv(envName) != "":
+		values = strings.Split(os.Getenv(envName), ",")
+	default:
+		values = configValues(configKey)
+	}
+
+	var result []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
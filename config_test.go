@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	data := `# go2v 配置
+mirror = https://a.example/dl
+
+  mirror=https://b.example/dl
+source = /srv/go # 不是注释
+	# indented comment
+empty =
+url = https://c.example/?a=b
+`
+	got, err := parseConfig([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"mirror": {"https://a.example/dl", "https://b.example/dl"},
+		"source": {"/srv/go # 不是注释"},
+		"empty":  {""},
+		"url":    {"https://c.example/?a=b"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseConfig = %q, want %q", got, want)
+	}
+
+	for _, bad := range []string{"mirror\n", "= value\n", "ok = 1\nno equals here\n"} {
+		if _, err := parseConfig([]byte(bad)); err == nil {
+			t.Errorf("parseConfig(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestSettingValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte("mirror = https://config.example\nmirror = \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(configFileEnv, path)
+
+	tests := []struct {
+		flags []string
+		env   string
+		want  []string
+	}{
+		{[]string{"https://flag.example", " "}, "https://env.example", []string{"https://flag.example"}},
+		{nil, "https://a.example, ,https://b.example ", []string{"https://a.example", "https://b.example"}},
+		{nil, "", []string{"https://config.example"}},
+	}
+	for _, tt := range tests {
+		t.Setenv("GO2V_TEST_MIRROR", tt.env)
+		if got := settingValues(tt.flags, "GO2V_TEST_MIRROR", "mirror"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("settingValues(%q, env %q) = %q, want %q", tt.flags, tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestConfigValuesIgnoresBadFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(configFileEnv, filepath.Join(dir, "missing"))
+	if got := configValues("mirror"); got != nil {
+		t.Errorf("configValues with missing file = %q, want nil", got)
+	}
+
+	path := filepath.Join(dir, "config")
+	if err := os.WriteFile(path, []byte("mirror = https://a.example\nbroken line\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(configFileEnv, path)
+	if got := configValues("mirror"); got != nil {
+		t.Errorf("configValues with unparsable file = %q, want nil", got)
+	}
+}
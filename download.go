@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// partFileSuffix 未下载完成的文件后缀
+	partFileSuffix = ".part"
+	// partStateSuffix 记录续传校验信息的文件后缀 (位于 .part 文件旁)
+	partStateSuffix = ".json"
+	// minSegmentSize 分段下载时每段的最小字节数，文件较小时减少分段数
+	minSegmentSize = 4 * 1024 * 1024
+)
+
+// errChecksumMismatch 下载内容与预期的 SHA-256 不一致
+var errChecksumMismatch = errors.New("checksum mismatch")
+
+// partialDownload 未完成下载的续传信息
+type partialDownload struct {
+	URL          string `json:"url"`                     // URL 下载地址，仅用于调试信息
+	ETag         string `json:"etag,omitempty"`          // ETag 首次响应中的强 ETag
+	LastModified string `json:"last_modified,omitempty"` // LastModified 首次响应中的 Last-Modified
+	Size         int64  `json:"-"`                       // Size .part 文件的当前大小
+}
+
+// validator 返回用于 If-Range 的校验值，优先使用 ETag；弱 ETag 不能用于 Range 请求
+func (p *partialDownload) validator() string {
+	if p.ETag != "" && !strings.HasPrefix(p.ETag, "W/") {
+		return p.ETag
+	}
+	return p.LastModified
+}
+
+// save 将续传信息写入 .part 文件旁的状态文件
+func (p *partialDownload) save(partPath string) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(partPath+partStateSuffix, data)
+}
+
+// newPartialDownload 根据响应头生成续传信息
+// 续传成功 (206) 时响应中可能不带校验头，此时沿用上次记录的值
+func newPartialDownload(url string, header http.Header, previous *partialDownload) *partialDownload {
+	p := &partialDownload{URL: url, ETag: header.Get("ETag"), LastModified: header.Get("Last-Modified")}
+	if previous != nil && p.ETag == "" && p.LastModified == "" {
+		p.ETag, p.LastModified = previous.ETag, previous.LastModified
+	}
+	return p
+}
+
+// loadPartialDownload 读取可以续传的未完成下载，没有 .part 文件或缺少校验信息时返回 nil
+func loadPartialDownload(partPath string) *partialDownload {
+	size := fileSize(partPath)
+	if size <= 0 {
+		return nil
+	}
+	data, err := os.ReadFile(partPath + partStateSuffix)
+	if err != nil {
+		debugPrint("Found %s without resume state, downloading from scratch", partPath)
+		return nil
+	}
+	var p partialDownload
+	if err := json.Unmarshal(data, &p); err != nil || p.validator() == "" {
+		debugPrint("Ignoring invalid resume state for %s: %v", partPath, err)
+		return nil
+	}
+	p.Size = size
+	return &p
+}
+
+// removePartialDownloadState 删除续传状态文件
+func removePartialDownloadState(partPath string) {
+	os.Remove(partPath + partStateSuffix)
+}
+
+// discardPartialDownload 删除未完成的下载及其续传状态
+func discardPartialDownload(partPath string) {
+	os.Remove(partPath)
+	removePartialDownloadState(partPath)
+}
+
+// downloadFileWithRetry 调用 downloadFile，下载中途中断且已有进展 (.part 文件变大) 时按退避策略重试并续传
+// 连接失败、5xx 等请求级别的错误已在 Transport 中重试过，这里不再重复
+func downloadFileWithRetry(url, path, expectedChecksum string) error {
+	partPath := path + partFileSuffix
+	for attempt := 0; ; attempt++ {
+		before := fileSize(partPath)
+		err := downloadFile(url, path, expectedChecksum)
+		if err == nil || errors.Is(err, errChecksumMismatch) || attempt >= httpRetries || fileSize(partPath) <= before {
+			return err
+		}
+		delay := retryDelay(attempt, nil)
+		fmt.Fprintf(os.Stderr, "Warning: Download of %s interrupted (%v), retrying in %s (%d/%d)\n", url, err, delay.Round(time.Millisecond), attempt+1, httpRetries)
+		time.Sleep(delay)
+	}
+}
+
+// contentRangeStart 解析 "bytes <start>-<end>/<size>" 格式的 Content-Range，返回起始位置
+func contentRangeStart(contentRange string) (int64, bool) {
+	spec, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(start), 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// contentRangeSize 解析 Content-Range 中的完整文件大小，大小未知 ("*") 时返回 false
+func contentRangeSize(contentRange string) (int64, bool) {
+	_, size, ok := strings.Cut(contentRange, "/")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(size), 10, 64)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// downloadSegment 分段下载中的一段，负责文件中 [Start, End) 的字节
+type downloadSegment struct {
+	Start, End int64
+	out        io.WriterAt
+	written    int64 // written 该段已写入的字节数
+}
+
+// Write 将数据写入该段在文件中的下一个位置，每段只由一个协程写入
+func (s *downloadSegment) Write(p []byte) (int, error) {
+	n, err := s.out.WriteAt(p, s.Start+s.written)
+	s.written += int64(n)
+	return n, err
+}
+
+// splitSegments 将 size 字节的文件最多分成 n 段，每段不小于 minSegmentSize
+func splitSegments(out io.WriterAt, size int64, n int) []*downloadSegment {
+	if maxSegments := size / minSegmentSize; int64(n) > maxSegments {
+		n = int(max(maxSegments, 1))
+	}
+	segments := make([]*downloadSegment, n)
+	for i := range segments {
+		segments[i] = &downloadSegment{
+			Start: size * int64(i) / int64(n),
+			End:   size * int64(i+1) / int64(n),
+			out:   out,
+		}
+	}
+	return segments
+}
+
+// contiguousSize 返回从文件开头连续下载完成的字节数，只有这部分可以用于续传
+func contiguousSize(segments []*downloadSegment) int64 {
+	var size int64
+	for _, s := range segments {
+		size += s.written
+		if s.written < s.End-s.Start {
+			break
+		}
+	}
+	return size
+}
+
+// downloadSegmented 并发下载各段并写入文件中对应的位置，所有段的进度都计入 progress
+// first 是请求 "bytes=0-" 得到的响应体，直接用作第一段；其余各段分别发出 Range 请求，
+// validator 非空时通过 If-Range 确保各段来自同一个文件
+func downloadSegmented(url string, first io.ReadCloser, segments []*downloadSegment, validator string, progress io.Writer) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// 任意一段失败时中止其余各段，第一段的响应不受 ctx 控制，需要直接关闭
+	stop := context.AfterFunc(ctx, func() { first.Close() })
+	defer stop()
+
+	errs := make([]error, len(segments))
+	var wg sync.WaitGroup
+	for i, seg := range segments {
+		wg.Add(1)
+		go func(i int, seg *downloadSegment) {
+			defer wg.Done()
+			body := io.Reader(first)
+			if i > 0 {
+				resp, err := requestSegment(ctx, url, seg, validator)
+				if err != nil {
+					errs[i] = err
+					cancel()
+					return
+				}
+				defer resp.Body.Close()
+				body = resp.Body
+			}
+			want := seg.End - seg.Start
+			n, err := io.Copy(io.MultiWriter(seg, progress), io.LimitReader(body, want))
+			if err == nil && n < want {
+				err = io.ErrUnexpectedEOF
+			}
+			if err != nil {
+				errs[i] = fmt.Errorf("segment %d-%d: %w", seg.Start, seg.End-1, err)
+				cancel()
+			}
+		}(i, seg)
+	}
+	wg.Wait()
+
+	// 优先返回引发中止的错误，而不是被中止的其他段的错误
+	var firstErr error
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if firstErr == nil || errors.Is(firstErr, context.Canceled) || errors.Is(firstErr, os.ErrClosed) {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// requestSegment 请求文件中的一段，服务器必须返回该段 (206)，否则说明文件已变化或不支持分段
+func requestSegment(ctx context.Context, url string, seg *downloadSegment, validator string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", seg.Start, seg.End-1))
+	if validator != "" {
+		req.Header.Set("If-Range", validator)
+	}
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		resp.Body.Close()
+		return nil, fmt.Errorf("segment %d-%d: server returned the full file (file changed during download?)", seg.Start, seg.End-1)
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("segment %d-%d: status code %d", seg.Start, seg.End-1, resp.StatusCode)
+	}
+	if start, ok := contentRangeStart(resp.Header.Get("Content-Range")); !ok || start != seg.Start {
+		resp.Body.Close()
+		return nil, fmt.Errorf("segment %d-%d: unexpected Content-Range %q", seg.Start, seg.End-1, resp.Header.Get("Content-Range"))
+	}
+	return resp, nil
+}
+
+// fileSize 返回文件大小，文件不存在时返回 0
+func fileSize(path string) int64 {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return fi.Size()
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testDownloadServer 可控制 Range 支持、校验头和故障的假下载服务器
+type testDownloadServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	data     []byte
+	etag     string          // etag 为空时不发送 ETag (也不发送 Last-Modified)
+	noRanges bool            // noRanges 忽略 Range 请求，总是返回完整文件
+	cuts     map[int64]int64 // cuts 按请求的起始位置，发送指定字节数后断开连接 (各生效一次)
+	failures map[int64]int   // failures 按请求的起始位置，返回 503 的次数
+	requests []testRangeRequest
+}
+
+// testRangeRequest 服务器收到的请求中与续传相关的请求头
+type testRangeRequest struct {
+	Range, IfRange string
+}
+
+// newTestDownloadServer 启动提供 data 的假下载服务器，测试结束时关闭
+func newTestDownloadServer(t *testing.T, data []byte, etag string) *testDownloadServer {
+	t.Helper()
+	s := &testDownloadServer{data: data, etag: etag, cuts: map[int64]int64{}, failures: map[int64]int{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// serve 处理下载请求
+func (s *testDownloadServer) serve(w http.ResponseWriter, r *http.Request) {
+	start := testRangeStart(r.Header.Get("Range"))
+	s.mu.Lock()
+	s.requests = append(s.requests, testRangeRequest{Range: r.Header.Get("Range"), IfRange: r.Header.Get("If-Range")})
+	data, etag, noRanges := s.data, s.etag, s.noRanges
+	fail := s.failures[start] > 0
+	if fail {
+		s.failures[start]--
+	}
+	cut, hasCut := s.cuts[start]
+	delete(s.cuts, start)
+	s.mu.Unlock()
+
+	if fail {
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if hasCut {
+		w = &cutResponseWriter{ResponseWriter: w, remaining: cut}
+	}
+	if noRanges {
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Write(data)
+		return
+	}
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+// setData 替换服务器上的文件，模拟新发布的文件
+func (s *testDownloadServer) setData(data []byte, etag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data, s.etag = data, etag
+}
+
+// received 返回服务器收到的所有请求
+func (s *testDownloadServer) received() []testRangeRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]testRangeRequest(nil), s.requests...)
+}
+
+// testRangeStart 返回 "bytes=<start>-[<end>]" 中的起始位置，没有 Range 时为 0
+func testRangeStart(rangeHeader string) int64 {
+	spec, _ := strings.CutPrefix(rangeHeader, "bytes=")
+	start, _, _ := strings.Cut(spec, "-")
+	n, _ := strconv.ParseInt(start, 10, 64)
+	return n
+}
+
+// cutResponseWriter 写出 remaining 字节后断开连接，模拟下载中途网络中断
+type cutResponseWriter struct {
+	http.ResponseWriter
+	remaining int64
+}
+
+// Write io.Writer 接口方法
+func (w *cutResponseWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) <= w.remaining {
+		w.remaining -= int64(len(p))
+		return w.ResponseWriter.Write(p)
+	}
+	w.ResponseWriter.Write(p[:w.remaining])
+	http.NewResponseController(w.ResponseWriter).Flush()
+	panic(http.ErrAbortHandler)
+}
+
+// testData 返回 n 字节的确定性伪随机数据
+func testData(n int, seed byte) []byte {
+	data := make([]byte, n)
+	rand.NewChaCha8([32]byte{seed}).Read(data)
+	return data
+}
+
+// testSHA256 返回 data 的 SHA-256 (十六进制)
+func testSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// checkDownloaded 确认下载结果与 want 一致，且没有留下 .part 文件和续传状态
+func checkDownloaded(t *testing.T, path string, want []byte) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("downloaded %d bytes (sha256 %s), want %d bytes (sha256 %s)", len(got), testSHA256(got), len(want), testSHA256(want))
+	}
+	for _, leftover := range []string{path + partFileSuffix, path + partFileSuffix + partStateSuffix} {
+		if _, err := os.Stat(leftover); err == nil {
+			t.Errorf("%s was left behind", leftover)
+		}
+	}
+}
+
+func TestDownloadFileResumesInterruptedDownload(t *testing.T) {
+	data := testData(1<<20, 1)
+	srv := newTestDownloadServer(t, data, `"v1"`)
+	srv.cuts[0] = 300_000
+	path := filepath.Join(t.TempDir(), "go.tar.gz")
+
+	if err := downloadFile(srv.URL, path, testSHA256(data)); err == nil {
+		t.Fatal("expected the interrupted download to fail")
+	}
+	kept := fileSize(path + partFileSuffix)
+	if kept <= 0 || kept >= int64(len(data)) {
+		t.Fatalf(".part file has %d bytes, want a partial download", kept)
+	}
+	if _, err := os.Stat(path + partFileSuffix + partStateSuffix); err != nil {
+		t.Fatalf("resume state not saved: %v", err)
+	}
+
+	if err := downloadFile(srv.URL, path, testSHA256(data)); err != nil {
+		t.Fatal(err)
+	}
+	checkDownloaded(t, path, data)
+	requests := srv.received()
+	if want := (testRangeRequest{Range: "bytes=" + strconv.FormatInt(kept, 10) + "-", IfRange: `"v1"`}); requests[len(requests)-1] != want {
+		t.Errorf("resume request = %+v, want %+v", requests[len(requests)-1], want)
+	}
+}
+
+func TestDownloadFileRestartsWhenFileChanged(t *testing.T) {
+	data := testData(1<<20, 1)
+	srv := newTestDownloadServer(t, data, `"v1"`)
+	srv.cuts[0] = 300_000
+	path := filepath.Join(t.TempDir(), "go.tar.gz")
+
+	if err := downloadFile(srv.URL, path, ""); err == nil {
+		t.Fatal("expected the interrupted download to fail")
+	}
+	// If-Range 不匹配，服务器返回完整的新文件
+	newData := testData(1<<20, 2)
+	srv.setData(newData, `"v2"`)
+	if err := downloadFile(srv.URL, path, testSHA256(newData)); err != nil {
+		t.Fatal(err)
+	}
+	checkDownloaded(t, path, newData)
+}
+
+func TestDownloadFileDiscardsPartWithoutValidator(t *testing.T) {
+	data := testData(1<<20, 1)
+	srv := newTestDownloadServer(t, data, "")
+	srv.cuts[0] = 300_000
+	path := filepath.Join(t.TempDir(), "go.tar.gz")
+
+	if err := downloadFile(srv.URL, path, ""); err == nil {
+		t.Fatal("expected the interrupted download to fail")
+	}
+	if _, err := os.Stat(path + partFileSuffix); err == nil {
+		t.Error(".part file kept although the server sent no ETag or Last-Modified")
+	}
+}
+
+func TestDownloadFileWithRetryResumes(t *testing.T) {
+	data := testData(1<<20, 1)
+	srv := newTestDownloadServer(t, data, `"v1"`)
+	srv.cuts[0] = 300_000
+	path := filepath.Join(t.TempDir(), "go.tar.gz")
+
+	if err := downloadFileWithRetry(srv.URL, path, testSHA256(data)); err != nil {
+		t.Fatal(err)
+	}
+	checkDownloaded(t, path, data)
+	if n := len(srv.received()); n != 2 {
+		t.Errorf("server received %d requests, want 2", n)
+	}
+}
+
+func TestDownloadFileChecksumMismatch(t *testing.T) {
+	srv := newTestDownloadServer(t, testData(1<<20, 1), `"v1"`)
+	path := filepath.Join(t.TempDir(), "go.tar.gz")
+
+	err := downloadFile(srv.URL, path, testSHA256([]byte("something else")))
+	if !errors.Is(err, errChecksumMismatch) {
+		t.Fatalf("error = %v, want a checksum mismatch", err)
+	}
+	for _, leftover := range []string{path, path + partFileSuffix, path + partFileSuffix + partStateSuffix} {
+		if _, err := os.Stat(leftover); err == nil {
+			t.Errorf("%s was left behind", leftover)
+		}
+	}
+}
+
+// useDownloadSegments 在测试期间将 --download-segments 设为 n
+func useDownloadSegments(t *testing.T, n int) {
+	t.Helper()
+	old := downloadSegments
+	downloadSegments = n
+	t.Cleanup(func() { downloadSegments = old })
+}
+
+func TestDownloadFileSegmented(t *testing.T) {
+	useDownloadSegments(t, 4)
+	data := testData(20*1024*1024, 3)
+	srv := newTestDownloadServer(t, data, `"v1"`)
+	path := filepath.Join(t.TempDir(), "go.tar.gz")
+
+	if err := downloadFile(srv.URL, path, testSHA256(data)); err != nil {
+		t.Fatal(err)
+	}
+	checkDownloaded(t, path, data)
+
+	// 先用 "bytes=0-" 探测 Range 支持，再并发请求其余各段
+	requests := srv.received()
+	if want := (testRangeRequest{Range: "bytes=0-"}); len(requests) == 0 || requests[0] != want {
+		t.Fatalf("first request = %+v, want %+v", requests, want)
+	}
+	got := map[testRangeRequest]bool{}
+	for _, r := range requests[1:] {
+		got[r] = true
+	}
+	segment := int64(len(data) / 4)
+	for i := int64(1); i < 4; i++ {
+		want := testRangeRequest{Range: "bytes=" + strconv.FormatInt(i*segment, 10) + "-" + strconv.FormatInt((i+1)*segment-1, 10), IfRange: `"v1"`}
+		if !got[want] {
+			t.Errorf("missing segment request %+v in %+v", want, requests)
+		}
+	}
+	if len(requests) != 4 {
+		t.Errorf("server received %d requests, want 4", len(requests))
+	}
+}
+
+func TestDownloadFileSegmentedFallsBackToSingleStream(t *testing.T) {
+	useDownloadSegments(t, 4)
+	data := testData(20*1024*1024, 3)
+	tests := []struct {
+		name     string
+		etag     string
+		noRanges bool
+	}{
+		{"ranges unsupported", `"v1"`, true},
+		{"no validator", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestDownloadServer(t, data, tt.etag)
+			srv.noRanges = tt.noRanges
+			path := filepath.Join(t.TempDir(), "go.tar.gz")
+
+			if err := downloadFile(srv.URL, path, testSHA256(data)); err != nil {
+				t.Fatal(err)
+			}
+			checkDownloaded(t, path, data)
+			if requests := srv.received(); len(requests) != 1 {
+				t.Errorf("server received %+v, want a single request", requests)
+			}
+		})
+	}
+}
+
+func TestDownloadFileRetriesFailedSegment(t *testing.T) {
+	useDownloadSegments(t, 4)
+	data := testData(20*1024*1024, 3)
+	srv := newTestDownloadServer(t, data, `"v1"`)
+	srv.failures[int64(len(data)/2)] = 1
+	path := filepath.Join(t.TempDir(), "go.tar.gz")
+
+	if err := downloadFile(srv.URL, path, testSHA256(data)); err != nil {
+		t.Fatal(err)
+	}
+	checkDownloaded(t, path, data)
+	if n := len(srv.received()); n != 5 {
+		t.Errorf("server received %d requests, want 5 (4 segments and 1 retry)", n)
+	}
+}
+
+func TestDownloadFileSegmentedKeepsContiguousPrefix(t *testing.T) {
+	useDownloadSegments(t, 4)
+	data := testData(20*1024*1024, 3)
+	srv := newTestDownloadServer(t, data, `"v1"`)
+	srv.cuts[int64(len(data)/2)] = 1 << 20
+	path := filepath.Join(t.TempDir(), "go.tar.gz")
+
+	if err := downloadFile(srv.URL, path, testSHA256(data)); err == nil {
+		t.Fatal("expected the interrupted download to fail")
+	}
+	// 只保留从开头连续下载完成的部分，其后不能有未下载的空洞
+	part, err := os.ReadFile(path + partFileSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(part, data[:len(part)]) {
+		t.Fatalf(".part file (%d bytes) is not a prefix of the file", len(part))
+	}
+
+	if err := downloadFile(srv.URL, path, testSHA256(data)); err != nil {
+		t.Fatal(err)
+	}
+	checkDownloaded(t, path, data)
+}
+
+func TestDownloadFileSegmentedChecksumMismatch(t *testing.T) {
+	useDownloadSegments(t, 4)
+	data := testData(20*1024*1024, 3)
+	srv := newTestDownloadServer(t, data, `"v1"`)
+	path := filepath.Join(t.TempDir(), "go.tar.gz")
+
+	// 校验和针对拼接后的完整文件计算，只看第一段不足以通过校验
+	err := downloadFile(srv.URL, path, testSHA256(data[:len(data)/4]))
+	if !errors.Is(err, errChecksumMismatch) {
+		t.Fatalf("error = %v, want a checksum mismatch", err)
+	}
+	if !strings.Contains(err.Error(), testSHA256(data)) {
+		t.Errorf("error %q does not report the sha256 of the assembled file", err)
+	}
+	if _, err := os.Stat(path + partFileSuffix); err == nil {
+		t.Error(".part file kept after a checksum mismatch")
+	}
+}
@@ -0,0 +1,593 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// extractLimits 解压时的资源上限，用于防御解压炸弹
+type extractLimits struct {
+	MaxTotalSize int64 // MaxTotalSize 所有文件解压后的总字节数上限
+	MaxFileSize  int64 // MaxFileSize 单个文件的字节数上限
+	MaxEntries   int   // MaxEntries 归档条目数上限
+}
+
+// defaultExtractLimits 默认解压上限，官方 Go 归档约 300 MiB / 15k 条目，留有充足余量
+var defaultExtractLimits = extractLimits{
+	MaxTotalSize: 2 << 30,
+	MaxFileSize:  512 << 20,
+	MaxEntries:   100000,
+}
+
+// extractError 表示某个归档条目被拒绝解压
+type extractError struct {
+	Entry  string // Entry 归档中的条目名
+	Reason string // Reason 拒绝原因
+}
+
+// Error error 接口方法
+func (e *extractError) Error() string {
+	return fmt.Sprintf("rejected archive entry %q: %s", e.Entry, e.Reason)
+}
+
+// extractTarGz 解压 tar.gz 文件到指定目录
+func extractTarGz(filePath, destDir string) error {
+	return extractTarGzWithLimits(filePath, destDir, defaultExtractLimits)
+}
+
+// extractTarGzWithLimits 按指定上限解压 tar.gz 文件到指定目录
+// 拒绝逃逸出目标目录的路径和链接、设备文件、FIFO 以及未知类型的条目
+// 还原符号链接、硬链接、权限和修改时间；PAX / GNU 长文件名由 archive/tar 负责解析
+func extractTarGzWithLimits(filePath, destDir string, limits extractLimits) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gzr, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gzr.Close()
+
+	destDir = filepath.Clean(destDir)
+	tr := tar.NewReader(gzr)
+	x := newExtractor(destDir, extractWorkers)
+	defer x.stop()
+
+	var totalSize int64
+	entries := 0
+	for {
+		// 后台写入出错时尽早停止
+		if err := x.err(); err != nil {
+			return err
+		}
+
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		// PAX 全局头 (例如 git archive 生成的 pax_global_header) 只包含元数据，没有对应的文件
+		if header.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+
+		entries++
+		if entries > limits.MaxEntries {
+			return &extractError{Entry: header.Name, Reason: fmt.Sprintf("archive has more than %d entries", limits.MaxEntries)}
+		}
+
+		target, err := sanitizeEntryPath(destDir, header.Name)
+		if err != nil {
+			return err
+		}
+		// 只有目录条目 (如 "./") 可以指向目标目录本身
+		if target == destDir && header.Typeflag != tar.TypeDir {
+			return &extractError{Entry: header.Name, Reason: "entry resolves to the destination directory itself"}
+		}
+		// 确保不会通过先前解压出的符号链接写到目标目录之外
+		if err := x.prepareParent(target, header.Name); err != nil {
+			return err
+		}
+		// 同一路径上还有未写完的文件时先等待，避免与写入协程同时修改该路径
+		if err := x.waitForPath(target); err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir: // 目录
+			if err := x.makeDir(target, header); err != nil {
+				return err
+			}
+		case tar.TypeReg: // 普通文件
+			if header.Size < 0 || header.Size > limits.MaxFileSize {
+				return &extractError{Entry: header.Name, Reason: fmt.Sprintf("file size %d exceeds limit of %d bytes", header.Size, limits.MaxFileSize)}
+			}
+			if totalSize+header.Size > limits.MaxTotalSize {
+				return &extractError{Entry: header.Name, Reason: fmt.Sprintf("total extracted size exceeds limit of %d bytes", limits.MaxTotalSize)}
+			}
+			if err := x.writeFile(target, header, tr); err != nil {
+				return err
+			}
+			totalSize += header.Size
+		case tar.TypeSymlink: // 符号链接
+			if err := x.checkLinkTarget(target, header); err != nil {
+				return err
+			}
+			if err := x.makeSymlink(target, header); err != nil {
+				return err
+			}
+		case tar.TypeLink: // 硬链接
+			if err := x.checkLinkTarget(target, header); err != nil {
+				return err
+			}
+			if err := x.makeHardlink(target, header); err != nil {
+				return err
+			}
+		case tar.TypeChar, tar.TypeBlock:
+			return &extractError{Entry: header.Name, Reason: "device files are not allowed"}
+		case tar.TypeFifo:
+			return &extractError{Entry: header.Name, Reason: "FIFO entries are not allowed"}
+		default:
+			return &extractError{Entry: header.Name, Reason: fmt.Sprintf("unsupported entry type %q", header.Typeflag)}
+		}
+	}
+
+	// 等待所有后台写入完成后再设置目录元数据
+	if err := x.stop(); err != nil {
+		return err
+	}
+	return x.finishDirs()
+}
+
+// extractor 保存单次解压过程中的状态
+// 小文件的内容由读取协程缓存后交给 workers 个写入协程并行写入，同时打开的文件数不超过 workers+1
+type extractor struct {
+	destDir       string
+	safeDirs      map[string]bool // safeDirs 已确认为真实目录 (非符号链接) 的路径
+	dirs          []dirMeta       // dirs 待内容写入完成后再应用的目录元数据
+	followedLinks map[string]bool // followedLinks 其他符号链接解析时经过的符号链接，不允许再被替换
+
+	jobs         chan extractJob // jobs 待写入的文件，为 nil 时所有文件均由读取协程顺序写入
+	pending      sync.WaitGroup  // pending 已提交但未写完的文件
+	workerWg     sync.WaitGroup
+	stopOnce     sync.Once
+	mu           sync.Mutex
+	pendingPaths map[string]int // pendingPaths 各路径上已提交但未写完的文件数
+	firstErr     error
+}
+
+// extractJob 交给写入协程的单个文件
+type extractJob struct {
+	target string
+	header *tar.Header
+	buf    *[]byte // buf 来自 fileDataPool 的缓冲区，内容为 (*buf)[:header.Size]
+}
+
+const (
+	// copyBufferSize 流式写入大文件时使用的复制缓冲区大小
+	copyBufferSize = 256 << 10
+	// parallelFileThreshold 不超过该大小的文件会被缓存到内存后交给写入协程
+	parallelFileThreshold = 1 << 20
+)
+
+var (
+	// copyBufferPool 复用流式复制缓冲区，避免每个文件重新分配
+	copyBufferPool = sync.Pool{New: func() any {
+		b := make([]byte, copyBufferSize)
+		return &b
+	}}
+	// fileDataPool 复用并行写入时缓存小文件内容的缓冲区
+	fileDataPool = sync.Pool{New: func() any {
+		b := make([]byte, parallelFileThreshold)
+		return &b
+	}}
+)
+
+// newExtractor 创建解压器，workers 大于 1 时启动对应数量的写入协程
+func newExtractor(destDir string, workers int) *extractor {
+	x := &extractor{
+		destDir:       destDir,
+		safeDirs:      map[string]bool{destDir: true},
+		followedLinks: map[string]bool{},
+		pendingPaths:  map[string]int{},
+	}
+	if workers > 1 {
+		x.jobs = make(chan extractJob, workers)
+		for i := 0; i < workers; i++ {
+			x.workerWg.Add(1)
+			go x.worker()
+		}
+		debugPrint("Extracting with %d parallel writers", workers)
+	}
+	return x
+}
+
+// worker 写入协程，出错后仅丢弃剩余任务，由读取协程负责停止
+func (x *extractor) worker() {
+	defer x.workerWg.Done()
+	for job := range x.jobs {
+		if x.err() == nil {
+			if _, err := writeRegularFile(job.target, job.header, bytes.NewReader((*job.buf)[:job.header.Size])); err != nil {
+				x.setErr(err)
+			}
+		}
+		fileDataPool.Put(job.buf)
+		x.mu.Lock()
+		if x.pendingPaths[job.target]--; x.pendingPaths[job.target] == 0 {
+			delete(x.pendingPaths, job.target)
+		}
+		x.mu.Unlock()
+		x.pending.Done()
+	}
+}
+
+// writeFile 写入普通文件：并行模式下小文件交给写入协程，其余直接流式写入
+func (x *extractor) writeFile(target string, header *tar.Header, r io.Reader) error {
+	if x.jobs == nil || header.Size > parallelFileThreshold {
+		_, err := writeRegularFile(target, header, r)
+		return err
+	}
+
+	buf := fileDataPool.Get().(*[]byte)
+	if _, err := io.ReadFull(r, (*buf)[:header.Size]); err != nil {
+		fileDataPool.Put(buf)
+		return &extractError{Entry: header.Name, Reason: fmt.Sprintf("failed to read content: %v", err)}
+	}
+	x.pending.Add(1)
+	x.mu.Lock()
+	x.pendingPaths[target]++
+	x.mu.Unlock()
+	x.jobs <- extractJob{target: target, header: header, buf: buf}
+	return nil
+}
+
+// wait 等待所有已提交的文件写入完成
+func (x *extractor) wait() error {
+	x.pending.Wait()
+	return x.err()
+}
+
+// waitForPath 若 target 上有已提交但未写完的文件，则等待所有已提交的文件写入完成
+func (x *extractor) waitForPath(target string) error {
+	x.mu.Lock()
+	busy := x.pendingPaths[target] > 0
+	x.mu.Unlock()
+	if !busy {
+		return nil
+	}
+	return x.wait()
+}
+
+// stop 关闭写入协程并等待其退出，可安全地多次调用
+func (x *extractor) stop() error {
+	x.stopOnce.Do(func() {
+		if x.jobs != nil {
+			close(x.jobs)
+			x.workerWg.Wait()
+		}
+	})
+	return x.err()
+}
+
+// err 返回写入协程遇到的第一个错误
+func (x *extractor) err() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.firstErr
+}
+
+// setErr 记录写入协程遇到的第一个错误
+func (x *extractor) setErr(err error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.firstErr == nil {
+		x.firstErr = err
+	}
+}
+
+// dirMeta 记录目录条目的权限和时间，在所有内容写入后再应用
+type dirMeta struct {
+	path  string
+	mode  os.FileMode
+	atime time.Time
+	mtime time.Time
+}
+
+// prepareParent 创建 target 的父目录，并确认路径上没有符号链接
+func (x *extractor) prepareParent(target, entryName string) error {
+	parent := filepath.Dir(target)
+	if x.safeDirs[parent] {
+		return nil
+	}
+
+	// 从目标目录开始逐级检查，遇到不存在的目录则创建
+	rel, err := filepath.Rel(x.destDir, parent)
+	if err != nil {
+		return err
+	}
+	current := x.destDir
+	for _, part := range strings.Split(rel, string(os.PathSeparator)) {
+		current = filepath.Join(current, part)
+		if x.safeDirs[current] {
+			continue
+		}
+		fi, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			if err := os.Mkdir(current, 0755); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		} else if fi.Mode()&os.ModeSymlink != 0 {
+			return &extractError{Entry: entryName, Reason: fmt.Sprintf("parent path %s is a symlink", current)}
+		} else if !fi.IsDir() {
+			return &extractError{Entry: entryName, Reason: fmt.Sprintf("parent path %s is not a directory", current)}
+		}
+		x.safeDirs[current] = true
+	}
+	return nil
+}
+
+// makeDir 创建目录条目，其最终权限和时间推迟到所有内容写入后再设置
+func (x *extractor) makeDir(target string, header *tar.Header) error {
+	fi, err := os.Lstat(target)
+	if os.IsNotExist(err) {
+		// 先以可写权限创建，避免只读目录阻止后续写入
+		if err := os.Mkdir(target, 0755); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else if !fi.IsDir() {
+		return &extractError{Entry: header.Name, Reason: "directory entry conflicts with an existing non-directory"}
+	} else if fi.Mode().Perm()&0700 != 0700 {
+		// 已存在的只读目录暂时放开属主权限，最终权限由 finishDirs 设置
+		if err := os.Chmod(target, fi.Mode().Perm()|0700); err != nil {
+			return err
+		}
+	}
+	x.safeDirs[target] = true
+	x.dirs = append(x.dirs, dirMeta{
+		path:  target,
+		mode:  entryMode(header),
+		atime: entryAccessTime(header),
+		mtime: header.ModTime,
+	})
+	return nil
+}
+
+// makeSymlink 创建符号链接并还原其时间戳
+func (x *extractor) makeSymlink(target string, header *tar.Header) error {
+	// 替换已被其他链接经过的符号链接会改变那些链接的指向，使其已做过的检查失效
+	if x.followedLinks[target] {
+		return &extractError{Entry: header.Name, Reason: "entry replaces a symlink that another link resolves through"}
+	}
+	if err := removeExisting(target, header); err != nil {
+		return err
+	}
+	if err := os.Symlink(header.Linkname, target); err != nil {
+		return err
+	}
+	if err := lchtimes(target, entryAccessTime(header), header.ModTime); err != nil {
+		debugPrint("Failed to set times on symlink %s: %v", target, err)
+	}
+	return nil
+}
+
+// makeHardlink 创建硬链接，链接源必须是已解压的普通文件
+func (x *extractor) makeHardlink(target string, header *tar.Header) error {
+	// 链接源可能仍在写入协程中，需要先等待其完成
+	if err := x.wait(); err != nil {
+		return err
+	}
+	source := filepath.Join(x.destDir, header.Linkname)
+	if !x.safeDirs[filepath.Dir(source)] {
+		return &extractError{Entry: header.Name, Reason: fmt.Sprintf("hardlink source %q was not extracted from this archive", header.Linkname)}
+	}
+	fi, err := os.Lstat(source)
+	if err != nil {
+		return &extractError{Entry: header.Name, Reason: fmt.Sprintf("hardlink source %q does not exist", header.Linkname)}
+	}
+	if !fi.Mode().IsRegular() {
+		return &extractError{Entry: header.Name, Reason: fmt.Sprintf("hardlink source %q is not a regular file", header.Linkname)}
+	}
+	if err := removeExisting(target, header); err != nil {
+		return err
+	}
+	return os.Link(source, target)
+}
+
+// finishDirs 按从深到浅的顺序应用目录权限和时间，避免写入子项时修改父目录的 mtime
+func (x *extractor) finishDirs() error {
+	for i := len(x.dirs) - 1; i >= 0; i-- {
+		d := x.dirs[i]
+		debugPrint("Setting mode of directory %s to %v", d.path, d.mode)
+		if err := os.Chmod(d.path, d.mode); err != nil {
+			return err
+		}
+		if err := os.Chtimes(d.path, d.atime, d.mtime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeRegularFile 将归档中的普通文件写入 target，返回实际写入的字节数
+// 写入完成后还原文件权限 (不受 umask 影响) 和时间
+func writeRegularFile(target string, header *tar.Header, r io.Reader) (int64, error) {
+	if err := removeExisting(target, header); err != nil {
+		return 0, err
+	}
+	// target 已被清空，O_EXCL 和 O_NOFOLLOW 确保不会写入其间出现的文件或符号链接
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY|oNoFollow, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	// 多读一个字节，以发现实际内容超过头部声明大小的异常条目
+	// 包装 f 以隐藏 ReadFrom，确保使用池化的复制缓冲区
+	buf := copyBufferPool.Get().(*[]byte)
+	written, err := io.CopyBuffer(struct{ io.Writer }{f}, io.LimitReader(r, header.Size+1), *buf)
+	copyBufferPool.Put(buf)
+	if err != nil {
+		return written, err
+	}
+	if written != header.Size {
+		return written, &extractError{Entry: header.Name, Reason: fmt.Sprintf("content size %d does not match header size %d", written, header.Size)}
+	}
+	if err := f.Chmod(entryMode(header)); err != nil {
+		return written, err
+	}
+	if err := f.Close(); err != nil {
+		return written, err
+	}
+	return written, os.Chtimes(target, entryAccessTime(header), header.ModTime)
+}
+
+// removeExisting 删除 target 处已存在的文件或链接 (不删除目录)，以便重新创建
+func removeExisting(target string, header *tar.Header) error {
+	fi, err := os.Lstat(target)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return &extractError{Entry: header.Name, Reason: "entry conflicts with an existing directory"}
+	}
+	return os.Remove(target)
+}
+
+// entryMode 返回条目的权限位，目录保留 setgid 和 sticky 位
+// 官方归档中的文件不带 setuid / setgid 位，以 root 身份安装时保留它们会让镜像可以植入 setuid 程序，因此去掉
+func entryMode(header *tar.Header) os.FileMode {
+	mode := header.FileInfo().Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+	if header.Typeflag != tar.TypeDir {
+		mode &^= os.ModeSetuid | os.ModeSetgid
+	}
+	return mode
+}
+
+// entryAccessTime 返回条目的访问时间，归档未记录时使用修改时间
+func entryAccessTime(header *tar.Header) time.Time {
+	if header.AccessTime.IsZero() {
+		return header.ModTime
+	}
+	return header.AccessTime
+}
+
+// sanitizeEntryPath 将条目名转换为目标目录内的路径，拒绝绝对路径和逃逸出目标目录的路径
+func sanitizeEntryPath(destDir, name string) (string, error) {
+	if name == "" {
+		return "", &extractError{Entry: name, Reason: "empty entry name"}
+	}
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "/") {
+		return "", &extractError{Entry: name, Reason: "absolute paths are not allowed"}
+	}
+
+	target := filepath.Join(destDir, name)
+	if !isWithinDir(destDir, target) {
+		return "", &extractError{Entry: name, Reason: "path escapes destination directory"}
+	}
+	return target, nil
+}
+
+// maxLinkDepth 解析链接目标时最多经过的符号链接层数，与 Linux 的 MAXSYMLINKS 相同
+const maxLinkDepth = 40
+
+// checkLinkTarget 检查符号链接或硬链接的目标是否位于目标目录内
+// 符号链接目标按磁盘上已解压的内容逐级解析，经过的符号链接也会被跟随
+func (x *extractor) checkLinkTarget(target string, header *tar.Header) error {
+	if header.Linkname == "" {
+		return &extractError{Entry: header.Name, Reason: "link has an empty target"}
+	}
+	if filepath.IsAbs(header.Linkname) || strings.HasPrefix(header.Linkname, "/") {
+		return &extractError{Entry: header.Name, Reason: fmt.Sprintf("link target %q is absolute", header.Linkname)}
+	}
+
+	if header.Typeflag == tar.TypeSymlink {
+		// 符号链接目标相对于链接自身所在目录
+		if _, err := x.resolveLink(filepath.Dir(target), header.Linkname, 0); err != nil {
+			return &extractError{Entry: header.Name, Reason: fmt.Sprintf("link target %q %v", header.Linkname, err)}
+		}
+		return nil
+	}
+	// 硬链接目标相对于归档根目录，makeHardlink 只接受已解压的真实目录中的文件
+	if !isWithinDir(x.destDir, filepath.Join(x.destDir, header.Linkname)) {
+		return &extractError{Entry: header.Name, Reason: fmt.Sprintf("link target %q escapes destination directory", header.Linkname)}
+	}
+	return nil
+}
+
+// resolveLink 从目录 dir 出发逐级解析链接目标 linkname，返回解析后的路径
+// 途经的符号链接按其目标继续解析 (并记录到 followedLinks)，任何一步离开目标目录都返回错误；
+// 尚不存在或不是目录的路径之后不能再出现 ".."，否则其含义会随之后解压的条目而改变
+func (x *extractor) resolveLink(dir, linkname string, depth int) (string, error) {
+	parts := strings.Split(filepath.ToSlash(linkname), "/")
+	current := dir
+	for i, part := range parts {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			current = filepath.Dir(current)
+			if !isWithinDir(x.destDir, current) {
+				return "", errors.New("escapes destination directory")
+			}
+			continue
+		}
+
+		next := filepath.Join(current, part)
+		fi, err := os.Lstat(next)
+		if err == nil && fi.Mode()&os.ModeSymlink != 0 {
+			if depth >= maxLinkDepth {
+				return "", errors.New("passes through too many levels of symlinks")
+			}
+			link, lerr := os.Readlink(next)
+			if lerr != nil {
+				return "", lerr
+			}
+			if filepath.IsAbs(link) || strings.HasPrefix(link, "/") {
+				return "", fmt.Errorf("passes through symlink %s to an absolute path", next)
+			}
+			x.followedLinks[next] = true
+			if next, lerr = x.resolveLink(current, link, depth+1); lerr != nil {
+				return "", lerr
+			}
+			fi, err = os.Lstat(next)
+		}
+		if (err != nil || !fi.IsDir()) && slices.Contains(parts[i+1:], "..") {
+			return "", fmt.Errorf("walks out of %s, which is not a directory", next)
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// isWithinDir 判断 path 是否为 dir 本身或位于 dir 之内 (两者均需为 Clean 后的路径)
+func isWithinDir(dir, path string) bool {
+	if path == dir {
+		return true
+	}
+	prefix := dir
+	if !strings.HasSuffix(prefix, string(os.PathSeparator)) {
+		prefix += string(os.PathSeparator)
+	}
+	return strings.HasPrefix(path, prefix)
+}
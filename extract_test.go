@@ -0,0 +1,422 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testTarEntry 构造测试归档时的单个条目
+type testTarEntry struct {
+	Header tar.Header
+	Body   string
+}
+
+// testFile 返回普通文件条目
+func testFile(name, body string) testTarEntry {
+	return testTarEntry{Header: tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(body))}, Body: body}
+}
+
+// testLink 返回符号链接或硬链接条目
+func testLink(typeflag byte, name, linkname string) testTarEntry {
+	return testTarEntry{Header: tar.Header{Name: name, Typeflag: typeflag, Linkname: linkname, Mode: 0777}}
+}
+
+// writeTestTarGz 将条目写入 dir 下的 tar.gz 文件并返回其路径
+func writeTestTarGz(t testing.TB, dir string, entries []testTarEntry) string {
+	t.Helper()
+	path := filepath.Join(dir, "archive.tar.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gzw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gzw)
+	for _, e := range entries {
+		hdr := e.Header
+		if hdr.ModTime.IsZero() && hdr.Typeflag != tar.TypeXGlobalHeader {
+			hdr.ModTime = time.Date(2024, 4, 2, 12, 0, 0, 0, time.UTC)
+		}
+		if err := tw.WriteHeader(&hdr); err != nil {
+			t.Fatalf("writing header %q: %v", hdr.Name, err)
+		}
+		if e.Body != "" {
+			if _, err := tw.Write([]byte(e.Body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractRejectsUnsafeEntries(t *testing.T) {
+	smallLimits := extractLimits{MaxTotalSize: 10, MaxFileSize: 6, MaxEntries: 3}
+	tests := []struct {
+		name    string
+		entries []testTarEntry
+		limits  extractLimits
+		reason  string
+	}{
+		{"path traversal", []testTarEntry{testFile("go/../../evil", "x")}, defaultExtractLimits, "escapes destination"},
+		{"absolute path", []testTarEntry{testFile("/etc/evil", "x")}, defaultExtractLimits, "absolute paths"},
+		{"entry is destination itself", []testTarEntry{testFile("go/..", "x")}, defaultExtractLimits, "destination directory itself"},
+		{"symlink escape", []testTarEntry{testLink(tar.TypeSymlink, "go/link", "../../outside")}, defaultExtractLimits, "escapes destination"},
+		{"absolute symlink", []testTarEntry{testLink(tar.TypeSymlink, "go/link", "/etc/passwd")}, defaultExtractLimits, "is absolute"},
+		{"write through symlink", []testTarEntry{
+			{Header: tar.Header{Name: "go/", Typeflag: tar.TypeDir, Mode: 0755}},
+			testLink(tar.TypeSymlink, "go/link", "."),
+			testFile("go/link/file", "x"),
+		}, defaultExtractLimits, "is a symlink"},
+		{"symlink escape through an earlier symlink", []testTarEntry{
+			{Header: tar.Header{Name: "go/", Typeflag: tar.TypeDir, Mode: 0755}},
+			testLink(tar.TypeSymlink, "go/s", ".."),
+			testLink(tar.TypeSymlink, "go/s2", "s/.."),
+		}, defaultExtractLimits, "escapes destination"},
+		{"symlink walks out of a missing path", []testTarEntry{
+			{Header: tar.Header{Name: "go/", Typeflag: tar.TypeDir, Mode: 0755}},
+			testLink(tar.TypeSymlink, "go/a", "later/../.."),
+		}, defaultExtractLimits, "not a directory"},
+		{"replace a symlink another link resolves through", []testTarEntry{
+			{Header: tar.Header{Name: "go/sub/", Typeflag: tar.TypeDir, Mode: 0755}},
+			testLink(tar.TypeSymlink, "go/sub/s", "."),
+			testLink(tar.TypeSymlink, "go/t", "sub/s/../.."),
+			testLink(tar.TypeSymlink, "go/sub/s", "../.."),
+		}, defaultExtractLimits, "another link resolves through"},
+		{"symlink loop", []testTarEntry{
+			{Header: tar.Header{Name: "go/", Typeflag: tar.TypeDir, Mode: 0755}},
+			testLink(tar.TypeSymlink, "go/a", "b"),
+			testLink(tar.TypeSymlink, "go/b", "a"),
+			testLink(tar.TypeSymlink, "go/c", "a/x"),
+		}, defaultExtractLimits, "too many levels"},
+		{"hardlink escape", []testTarEntry{testLink(tar.TypeLink, "go/link", "../outside")}, defaultExtractLimits, "escapes destination"},
+		{"absolute hardlink", []testTarEntry{testLink(tar.TypeLink, "go/link", "/etc/passwd")}, defaultExtractLimits, "is absolute"},
+		{"hardlink to missing source", []testTarEntry{testLink(tar.TypeLink, "go/link", "go/missing")}, defaultExtractLimits, "hardlink source"},
+		{"hardlink to symlink", []testTarEntry{
+			testLink(tar.TypeSymlink, "go/sym", "file"),
+			testLink(tar.TypeLink, "go/link", "go/sym"),
+		}, defaultExtractLimits, "not a regular file"},
+		{"character device", []testTarEntry{{Header: tar.Header{Name: "go/tty", Typeflag: tar.TypeChar, Mode: 0600}}}, defaultExtractLimits, "device files"},
+		{"block device", []testTarEntry{{Header: tar.Header{Name: "go/sda", Typeflag: tar.TypeBlock, Mode: 0600}}}, defaultExtractLimits, "device files"},
+		{"FIFO", []testTarEntry{{Header: tar.Header{Name: "go/fifo", Typeflag: tar.TypeFifo, Mode: 0600}}}, defaultExtractLimits, "FIFO"},
+		{"file too large", []testTarEntry{testFile("go/big", "1234567")}, smallLimits, "file size 7 exceeds"},
+		{"total size too large", []testTarEntry{testFile("go/a", "123456"), testFile("go/b", "123456")}, smallLimits, "total extracted size"},
+		{"too many entries", []testTarEntry{testFile("go/a", "1"), testFile("go/b", "1"), testFile("go/c", "1"), testFile("go/d", "1")}, smallLimits, "more than 3 entries"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			archive := writeTestTarGz(t, dir, tt.entries)
+			dest := filepath.Join(dir, "dest")
+			if err := os.Mkdir(dest, 0755); err != nil {
+				t.Fatal(err)
+			}
+			err := extractTarGzWithLimits(archive, dest, tt.limits)
+			var xerr *extractError
+			if !errors.As(err, &xerr) {
+				t.Fatalf("error = %v, want an extractError", err)
+			}
+			if !strings.Contains(xerr.Reason, tt.reason) {
+				t.Errorf("reason = %q, want it to contain %q", xerr.Reason, tt.reason)
+			}
+			if _, err := os.Lstat(filepath.Join(dir, "outside")); err == nil {
+				t.Error("entry was written outside the destination directory")
+			}
+		})
+	}
+}
+
+func TestExtractRestoresLinks(t *testing.T) {
+	dir := t.TempDir()
+	archive := writeTestTarGz(t, dir, []testTarEntry{
+		{Header: tar.Header{Name: "go/", Typeflag: tar.TypeDir, Mode: 0755}},
+		{Header: tar.Header{Name: "go/bin/go", Typeflag: tar.TypeReg, Mode: 0755, Size: 2}, Body: "go"},
+		testLink(tar.TypeSymlink, "go/bin/golink", "go"),
+		testLink(tar.TypeLink, "go/bin/gohard", "go/bin/go"),
+		// 经过已解压的符号链接后仍位于目标目录内的链接
+		testLink(tar.TypeSymlink, "go/tools", "bin"),
+		testLink(tar.TypeSymlink, "go/gobin", "tools/../bin/go"),
+	})
+	dest := filepath.Join(dir, "dest")
+	os.Mkdir(dest, 0755)
+	if err := extractTarGz(archive, dest); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(filepath.Join(dest, "go/bin/go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0755 {
+		t.Errorf("mode = %v, want 0755", fi.Mode().Perm())
+	}
+	if target, err := os.Readlink(filepath.Join(dest, "go/bin/golink")); err != nil || target != "go" {
+		t.Errorf("symlink target = %q, %v", target, err)
+	}
+	hard, err := os.Stat(filepath.Join(dest, "go/bin/gohard"))
+	if err != nil || !os.SameFile(fi, hard) {
+		t.Errorf("hardlink does not share the source file: %v", err)
+	}
+	if viaLink, err := os.Stat(filepath.Join(dest, "go/gobin")); err != nil || !os.SameFile(fi, viaLink) {
+		t.Errorf("go/gobin does not resolve to go/bin/go: %v", err)
+	}
+}
+
+func TestExtractRestoresModTimes(t *testing.T) {
+	dirTime := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	fileTime := time.Date(2023, 6, 7, 8, 9, 10, 0, time.UTC)
+	linkTime := time.Date(2022, 11, 12, 13, 14, 15, 0, time.UTC)
+	dir := t.TempDir()
+	archive := writeTestTarGz(t, dir, []testTarEntry{
+		// 目录条目在其子项之前，子项写入后目录的 mtime 仍需为归档中的值
+		{Header: tar.Header{Name: "go/", Typeflag: tar.TypeDir, Mode: 0755, ModTime: dirTime}},
+		{Header: tar.Header{Name: "go/bin/", Typeflag: tar.TypeDir, Mode: 0755, ModTime: dirTime}},
+		{Header: tar.Header{Name: "go/bin/go", Typeflag: tar.TypeReg, Mode: 0755, Size: 2, ModTime: fileTime}, Body: "go"},
+		{Header: tar.Header{Name: "go/bin/golink", Typeflag: tar.TypeSymlink, Linkname: "go", Mode: 0777, ModTime: linkTime}},
+	})
+	dest := filepath.Join(dir, "dest")
+	os.Mkdir(dest, 0755)
+	if err := extractTarGz(archive, dest); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]time.Time{
+		"go":            dirTime,
+		"go/bin":        dirTime,
+		"go/bin/go":     fileTime,
+		"go/bin/golink": linkTime,
+	}
+	if runtime.GOOS != "linux" {
+		// 仅 Linux 支持设置符号链接自身的时间
+		delete(want, "go/bin/golink")
+	}
+	for name, mtime := range want {
+		fi, err := os.Lstat(filepath.Join(dest, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !fi.ModTime().Equal(mtime) {
+			t.Errorf("%s: mtime = %v, want %v", name, fi.ModTime().UTC(), mtime)
+		}
+	}
+}
+
+func TestExtractSkipsPAXGlobalHeader(t *testing.T) {
+	dir := t.TempDir()
+	archive := writeTestTarGz(t, dir, []testTarEntry{
+		{Header: tar.Header{Name: "pax_global_header", Typeflag: tar.TypeXGlobalHeader, PAXRecords: map[string]string{"comment": "0123abcd"}}},
+		testFile("go/VERSION", "go1.22.2"),
+	})
+	dest := filepath.Join(dir, "dest")
+	os.Mkdir(dest, 0755)
+	if err := extractTarGz(archive, dest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "go/VERSION")); err != nil {
+		t.Error(err)
+	}
+	if _, err := os.Lstat(filepath.Join(dest, "pax_global_header")); err == nil {
+		t.Error("pax_global_header was extracted as a file")
+	}
+}
+
+func TestExtractRestoresSpecialModeBits(t *testing.T) {
+	dir := t.TempDir()
+	archive := writeTestTarGz(t, dir, []testTarEntry{
+		{Header: tar.Header{Name: "go/shared/", Typeflag: tar.TypeDir, Mode: 0o1777}},
+		{Header: tar.Header{Name: "go/group/", Typeflag: tar.TypeDir, Mode: 0o2755}},
+		{Header: tar.Header{Name: "go/bin/tool", Typeflag: tar.TypeReg, Mode: 0o4755, Size: 4}, Body: "tool"},
+		{Header: tar.Header{Name: "go/bin/sgid", Typeflag: tar.TypeReg, Mode: 0o2755, Size: 4}, Body: "sgid"},
+	})
+	dest := filepath.Join(dir, "dest")
+	os.Mkdir(dest, 0755)
+	if err := extractTarGz(archive, dest); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]os.FileMode{
+		"go/shared":   os.ModeSticky | 0777,
+		"go/group":    os.ModeSetgid | 0755,
+		"go/bin/tool": 0755, // 普通文件的 setuid / setgid 位被去掉
+		"go/bin/sgid": 0755,
+	} {
+		fi, err := os.Stat(filepath.Join(dest, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fi.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky); got != want {
+			t.Errorf("%s: mode = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestExtractWithParallelWriters(t *testing.T) {
+	large := strings.Repeat("x", parallelFileThreshold+1)
+	entries := []testTarEntry{
+		{Header: tar.Header{Name: "go/", Typeflag: tar.TypeDir, Mode: 0755}},
+		testFile("go/victim", "original"),
+		// 小文件交给写入协程后，同一路径又被替换为符号链接，写入协程不能跟随该链接
+		testFile("go/a", "payload"),
+		testLink(tar.TypeSymlink, "go/a", "victim"),
+		// 重复的普通文件以后出现的为准
+		testFile("go/dup", "first"),
+		testFile("go/dup", "second"),
+		testFile("go/large", large),
+		testLink(tar.TypeLink, "go/hard", "go/dup"),
+	}
+	for i := 0; i < 50; i++ {
+		entries = append(entries, testFile(fmt.Sprintf("go/src/f%02d.go", i), fmt.Sprintf("package f%d\n", i)))
+	}
+
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			defer func(old int) { extractWorkers = old }(extractWorkers)
+			extractWorkers = workers
+			dir := t.TempDir()
+			archive := writeTestTarGz(t, dir, entries)
+			dest := filepath.Join(dir, "dest")
+			os.Mkdir(dest, 0755)
+			if err := extractTarGz(archive, dest); err != nil {
+				t.Fatal(err)
+			}
+
+			want := map[string]string{
+				"go/victim": "original",
+				"go/a":      "original",
+				"go/dup":    "second",
+				"go/hard":   "second",
+				"go/large":  large,
+			}
+			for i := 0; i < 50; i++ {
+				want[fmt.Sprintf("go/src/f%02d.go", i)] = fmt.Sprintf("package f%d\n", i)
+			}
+			for name, body := range want {
+				data, err := os.ReadFile(filepath.Join(dest, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(data) != body {
+					t.Errorf("%s = %.20q, want %.20q", name, data, body)
+				}
+			}
+			if target, err := os.Readlink(filepath.Join(dest, "go/a")); err != nil || target != "victim" {
+				t.Errorf("go/a: symlink target = %q, %v", target, err)
+			}
+		})
+	}
+}
+
+// benchmarkArchive 返回用于基准测试的 Go 发布归档
+// 设置 GO2V_BENCH_ARCHIVE 时使用指定的官方归档，否则将当前 GOROOT 打包成与官方归档相同结构的 tar.gz
+func benchmarkArchive(b *testing.B) string {
+	b.Helper()
+	if path := os.Getenv("GO2V_BENCH_ARCHIVE"); path != "" {
+		return path
+	}
+	out, err := exec.Command("go", "env", "GOROOT").Output()
+	if err != nil {
+		b.Skipf("cannot locate GOROOT: %v", err)
+	}
+	goroot := strings.TrimSpace(string(out))
+
+	path := filepath.Join(b.TempDir(), "go.tar.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	gzw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gzw)
+	err = filepath.WalkDir(goroot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		var link string
+		if fi.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		} else if !fi.Mode().IsRegular() && !fi.IsDir() {
+			return nil
+		}
+		hdr, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(goroot, p)
+		hdr.Name = filepath.ToSlash(filepath.Join("go", rel))
+		if fi.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if fi.Mode().IsRegular() {
+			src, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer src.Close()
+			_, err = io.Copy(tw, src)
+			return err
+		}
+		return nil
+	})
+	if err == nil {
+		err = tw.Close()
+	}
+	if err == nil {
+		err = gzw.Close()
+	}
+	if err != nil {
+		b.Fatalf("packing %s: %v", goroot, err)
+	}
+	return path
+}
+
+// BenchmarkExtract 比较顺序写入与不同数量写入协程解压完整 Go 发布归档的耗时
+func BenchmarkExtract(b *testing.B) {
+	archive := benchmarkArchive(b)
+	fi, err := os.Stat(archive)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			defer func(old int) { extractWorkers = old }(extractWorkers)
+			extractWorkers = workers
+			b.SetBytes(fi.Size())
+			for i := 0; i < b.N; i++ {
+				dest := filepath.Join(b.TempDir(), "dest")
+				if err := os.Mkdir(dest, 0755); err != nil {
+					b.Fatal(err)
+				}
+				if err := extractTarGz(archive, dest); err != nil {
+					b.Fatal(err)
+				}
+				b.StopTimer()
+				os.RemoveAll(dest)
+				b.StartTimer()
+			}
+		})
+	}
+}
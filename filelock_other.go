@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package main
+
+// fileLockSupported 当前平台是否支持 lockFile
+const fileLockSupported = false
+
+// lockFile 对 path 加排他锁 (Fallback for other OS)
+// 标准库未提供跨平台的文件锁，此处不加锁
+func lockFile(path string) (func(), error) {
+	debugPrint("File locking is not supported on this OS, not locking %s", path)
+	return func() {}, nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// goModFileName 模块定义文件名
+const goModFileName = "go.mod"
+
+// goDirectives 表示 go.mod / go.work 中与 Go 版本相关的指令
+type goDirectives struct {
+	Go        string   // Go "go" 指令声明的语言版本 (例如 "1.22" 或 "1.22.3")
+	Toolchain string   // Toolchain "toolchain" 指令声明的工具链版本，已去掉 "go" 前缀
+	Uses      []string // Uses go.work 中 "use" 指令列出的模块目录
+}
+
+// versionSource 描述从文件中得到的版本要求及其来源
+type versionSource struct {
+	Spec      string // Spec 交给版本解析器的版本表达式
+	File      string // File 版本要求所在的文件
+	Directive string // Directive 使用的 go.mod 指令 (例如 "toolchain"、"go")，来自版本文件时为空
+}
+
+// String 返回便于提示用户的来源描述
+func (s versionSource) String() string {
+	if s.Directive == "" {
+		return fmt.Sprintf("%s (from %s)", s.Spec, s.File)
+	}
+	return fmt.Sprintf("%s (%s directive in %s)", s.Spec, s.Directive, s.File)
+}
+
+// parseGoDirectives 解析 go.mod / go.work 内容中的 go、toolchain 和 use 指令
+// 其他指令 (包括 require ( ... ) 等块) 会被忽略
+func parseGoDirectives(data []byte) (goDirectives, error) {
+	var d goDirectives
+	block := "" // 当前所在的 "verb ( ... )" 块
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		fields := directiveFields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		if block != "" {
+			if fields[0] == ")" {
+				block = ""
+				continue
+			}
+			fields = append([]string{block}, fields...)
+		} else if len(fields) == 2 && fields[1] == "(" {
+			block = fields[0]
+			continue
+		}
+
+		switch fields[0] {
+		case "go":
+			if len(fields) != 2 {
+				return d, fmt.Errorf("line %d: malformed go directive", lineNum)
+			}
+			if _, err := parseGoVersion(fields[1]); err != nil {
+				return d, fmt.Errorf("line %d: invalid go version %q", lineNum, fields[1])
+			}
+			d.Go = fields[1]
+		case "toolchain":
+			if len(fields) != 2 {
+				return d, fmt.Errorf("line %d: malformed toolchain directive", lineNum)
+			}
+			d.Toolchain = normalizeToolchainName(fields[1])
+			if d.Toolchain == "" {
+				return d, fmt.Errorf("line %d: invalid toolchain name %q", lineNum, fields[1])
+			}
+		case "use":
+			if len(fields) != 2 {
+				return d, fmt.Errorf("line %d: malformed use directive", lineNum)
+			}
+			dir := fields[1]
+			if strings.HasPrefix(dir, `"`) || strings.HasPrefix(dir, "`") {
+				unquoted, err := strconv.Unquote(dir)
+				if err != nil {
+					return d, fmt.Errorf("line %d: invalid use path %s", lineNum, dir)
+				}
+				dir = unquoted
+			}
+			d.Uses = append(d.Uses, dir)
+		}
+	}
+	return d, scanner.Err()
+}
+
+// directiveFields 将一行拆分为字段，去掉 "//" 注释
+// 与 go 命令一致，带引号的字段 (例如 use "./my module") 可以包含空格和 "//"，引号保留在字段中
+func directiveFields(line string) []string {
+	var fields []string
+	for {
+		line = strings.TrimLeft(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "//") {
+			return fields
+		}
+		end := strings.IndexAny(line, " \t\r")
+		switch line[0] {
+		case '"':
+			// 跳过转义的引号，未闭合时取到行尾，由调用方报告错误
+			end = len(line)
+			for i := 1; i < len(line); i++ {
+				if line[i] == '\\' {
+					i++
+				} else if line[i] == '"' {
+					end = i + 1
+					break
+				}
+			}
+		case '`':
+			if i := strings.IndexByte(line[1:], '`'); i >= 0 {
+				end = i + 2
+			} else {
+				end = len(line)
+			}
+		default:
+			if i := strings.Index(line, "//"); i >= 0 && (end < 0 || i < end) {
+				end = i
+			}
+		}
+		if end < 0 {
+			end = len(line)
+		}
+		fields = append(fields, line[:end])
+		line = line[end:]
+	}
+}
+
+// normalizeToolchainName 将 toolchain 名称 (例如 "go1.22.5" 或 "go1.22.5-custom") 转换为版本号
+// 无法识别时返回空字符串
+func normalizeToolchainName(name string) string {
+	if !strings.HasPrefix(name, "go") {
+		return ""
+	}
+	v := strings.TrimPrefix(name, "go")
+	// 自定义工具链可以带有 "-suffix" 或 "+suffix"，只保留版本部分
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	if _, err := parseGoVersion(v); err != nil {
+		return ""
+	}
+	return v
+}
+
+// readGoDirectives 读取文件中的 go 和 toolchain 指令
+func readGoDirectives(path string) (goDirectives, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return goDirectives{}, err
+	}
+	d, err := parseGoDirectives(data)
+	if err != nil {
+		return d, fmt.Errorf("%s: %w", path, err)
+	}
+	return d, nil
+}
+
+// findFileUpwards 从 startDir 开始逐级向上查找名为 name 的文件，未找到时返回空字符串
+func findFileUpwards(startDir, name string) (string, error) {
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		candidate := filepath.Join(dir, name)
+		if fi, err := os.Stat(candidate); err == nil && !fi.IsDir() {
+			return candidate, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// versionFromGoMod 查找 dir 所在模块的 go.mod，优先使用 toolchain 指令，其次使用 go 指令
+// 未找到 go.mod 时返回 found == false
+func versionFromGoMod(dir string) (src versionSource, found bool, err error) {
+	goModPath, err := findFileUpwards(dir, goModFileName)
+	if err != nil || goModPath == "" {
+		return versionSource{}, false, err
+	}
+	debugPrint("Found %s", goModPath)
+
+	d, err := readGoDirectives(goModPath)
+	if err != nil {
+		return versionSource{}, true, err
+	}
+	switch {
+	case d.Toolchain != "":
+		return versionSource{Spec: d.Toolchain, File: goModPath, Directive: "toolchain"}, true, nil
+	case d.Go != "":
+		return versionSource{Spec: d.Go, File: goModPath, Directive: "go"}, true, nil
+	default:
+		return versionSource{}, true, fmt.Errorf("%s has neither a toolchain nor a go directive", goModPath)
+	}
+}
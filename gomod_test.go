@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseGoDirectives(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want goDirectives
+	}{
+		{"go only", "module example.com/m\n\ngo 1.22\n", goDirectives{Go: "1.22"}},
+		{"go and toolchain", "module m\ngo 1.21.0\ntoolchain go1.22.5\n", goDirectives{Go: "1.21.0", Toolchain: "1.22.5"}},
+		{"custom toolchain suffix", "go 1.22\ntoolchain go1.22.5-custom+build\n", goDirectives{Go: "1.22", Toolchain: "1.22.5"}},
+		{"prerelease go version", "go 1.23rc1\n", goDirectives{Go: "1.23rc1"}},
+		{"comments", "// go 1.10\ngo 1.22 // minimum\n", goDirectives{Go: "1.22"}},
+		{"require block ignored", "module m\nrequire (\n\texample.com/go v1.0.0\n\tgo v1.2.3\n)\ngo 1.22\n", goDirectives{Go: "1.22"}},
+		{"no directives", "module m\n", goDirectives{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGoDirectives([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	for _, bad := range []string{
+		"go\n",
+		"go 1.22 extra\n",
+		"go one.two\n",
+		"toolchain\n",
+		"toolchain default\n",
+		"toolchain go1.x\n",
+	} {
+		if d, err := parseGoDirectives([]byte(bad)); err == nil {
+			t.Errorf("parseGoDirectives(%q) = %+v, want an error", bad, d)
+		}
+	}
+}
+
+func TestVersionFromGoMod(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("tool/go.mod", "module tool\ngo 1.21\ntoolchain go1.22.5\n")
+	write("plain/go.mod", "module plain\ngo 1.22.3\n")
+	write("empty/go.mod", "module empty\n")
+	os.MkdirAll(filepath.Join(root, "plain/internal/pkg"), 0755)
+	os.MkdirAll(filepath.Join(root, "none"), 0755)
+
+	tests := []struct {
+		dir       string
+		found     bool
+		spec      string
+		directive string
+		wantErr   string
+	}{
+		{"tool", true, "1.22.5", "toolchain", ""},
+		{"plain/internal/pkg", true, "1.22.3", "go", ""}, // 从子目录向上查找
+		{"empty", true, "", "", "neither a toolchain nor a go directive"},
+		{"none", false, "", "", ""},
+	}
+	for _, tt := range tests {
+		src, found, err := versionFromGoMod(filepath.Join(root, tt.dir))
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want %q", tt.dir, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.dir, err)
+			continue
+		}
+		// TempDir 之外可能存在 go.mod，"none" 只检查没有找到 root 下的文件
+		if !tt.found {
+			if found && strings.HasPrefix(src.File, root) {
+				t.Errorf("%s: found %s, want no go.mod", tt.dir, src.File)
+			}
+			continue
+		}
+		if !found || src.Spec != tt.spec || src.Directive != tt.directive {
+			t.Errorf("%s: got %+v (found %v), want %s from the %s directive", tt.dir, src, found, tt.spec, tt.directive)
+		}
+	}
+}
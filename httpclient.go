@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// httpConnectTimeout 建立 TCP 连接的超时时间
+	httpConnectTimeout = 15 * time.Second
+	// httpTLSHandshakeTimeout TLS 握手的超时时间
+	httpTLSHandshakeTimeout = 15 * time.Second
+	// httpResponseHeaderTimeout 发出请求后等待响应头的超时时间
+	httpResponseHeaderTimeout = 30 * time.Second
+	// httpIdleConnTimeout 空闲的 keep-alive 连接保留的时间
+	httpIdleConnTimeout = 90 * time.Second
+	// httpRequestTimeout 元数据、校验和等小请求的整体超时时间 (包括读取响应体)
+	httpRequestTimeout = 2 * time.Minute
+	// httpStallTimeout 读取响应体时连续多久收不到数据视为连接停滞；归档下载没有整体超时，只受此限制
+	httpStallTimeout = time.Minute
+	// defaultHTTPRetries 网络错误、5xx 和 429 响应的默认重试次数
+	defaultHTTPRetries = 3
+	// retryBaseDelay 第一次重试前的等待时间，之后每次翻倍
+	retryBaseDelay = 500 * time.Millisecond
+	// retryMaxDelay 单次重试前的最长等待时间
+	retryMaxDelay = 30 * time.Second
+)
+
+// httpRetries 可重试的请求失败后最多重试的次数，0 表示不重试
+var httpRetries = defaultHTTPRetries
+
+// errStalled 读取响应体时长时间收不到数据
+var errStalled = fmt.Errorf("connection stalled: no data received for %s", httpStallTimeout)
+
+var (
+	// httpTransport 所有 HTTP 请求共用的 Transport，带有超时、重试和停滞检测
+	httpTransport = newHTTPTransport()
+	// httpClient 访问版本来源、校验和、签名等小文件时共用的 HTTP 客户端，有整体超时
+	httpClient = &http.Client{Transport: httpTransport, Timeout: httpRequestTimeout, CheckRedirect: checkRedirect}
+	// downloadClient 下载归档时使用的 HTTP 客户端，大文件在慢速网络上耗时不定，没有整体超时
+	downloadClient = &http.Client{Transport: httpTransport, CheckRedirect: checkRedirect}
+	// localFileClient 读取本地目录来源 file:// 地址的客户端，只有 URL 本身是 file:// 时才会使用
+	localFileClient = &http.Client{Transport: http.NewFileTransport(http.Dir("/"))}
+)
+
+// clientFor 返回访问 rawURL 应使用的客户端：file:// 地址使用 localFileClient，其余使用 remote
+// file 协议不注册在共用的 Transport 上，远程服务器无法通过重定向让 go2v 读取本地文件
+func clientFor(rawURL string, remote *http.Client) *http.Client {
+	if isLocalURL(rawURL) {
+		return localFileClient
+	}
+	return remote
+}
+
+// checkRedirect 只允许重定向到 http(s) 地址，其余与默认策略相同 (最多 10 次)
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return fmt.Errorf("refusing redirect to %s: only http and https redirects are allowed", req.URL.Redacted())
+	}
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	return nil
+}
+
+// newHTTPTransport 返回带有连接超时、可重试错误自动重试和停滞检测的 Transport
+func newHTTPTransport() http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = (&net.Dialer{Timeout: httpConnectTimeout, KeepAlive: 30 * time.Second}).DialContext
+	t.TLSHandshakeTimeout = httpTLSHandshakeTimeout
+	t.ResponseHeaderTimeout = httpResponseHeaderTimeout
+	t.IdleConnTimeout = httpIdleConnTimeout
+	return &stallTransport{base: &retryTransport{base: t}, timeout: httpStallTimeout}
+}
+
+// retryTransport 对网络错误、5xx 和 429 响应按带抖动的指数退避重试
+type retryTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip http.RoundTripper 接口方法
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// 带请求体的请求无法安全地重放
+	if req.Body != nil && req.Body != http.NoBody {
+		return t.base.RoundTrip(req)
+	}
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		reason, retry := retryReason(req, resp, err)
+		if !retry || attempt >= httpRetries {
+			return resp, err
+		}
+
+		delay := retryDelay(attempt, resp)
+		if resp != nil {
+			// 读完 (少量) 响应体以便复用连接
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %s: %s, retrying in %s (%d/%d)\n", req.URL.Redacted(), reason, delay.Round(time.Millisecond), attempt+1, httpRetries)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// noRetryKey 标记不需要重试的请求的 context key
+type noRetryKey struct{}
+
+// withoutRetries 返回关闭自动重试的 context，用于失败本身就是结果的请求 (例如镜像测速)
+func withoutRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetryKey{}, true)
+}
+
+// retryReason 判断请求结果是否值得重试，返回用于提示的原因
+// 证书错误等不会因重试而改变的错误、调用方已取消或关闭了重试的请求不重试
+func retryReason(req *http.Request, resp *http.Response, err error) (string, bool) {
+	if req.Context().Err() != nil || req.Context().Value(noRetryKey{}) != nil {
+		return "", false
+	}
+	if err != nil {
+		var certErr *tls.CertificateVerificationError
+		if errors.As(err, &certErr) {
+			return "", false
+		}
+		return err.Error(), true
+	}
+	if resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented) {
+		return fmt.Sprintf("status code %d", resp.StatusCode), true
+	}
+	return "", false
+}
+
+// retryDelay 返回第 attempt 次重试前的等待时间
+// 优先使用响应中的 Retry-After (秒)，否则按指数退避并加入随机抖动，避免多个客户端同时重试
+func retryDelay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+			return min(time.Duration(secs)*time.Second, retryMaxDelay)
+		}
+	}
+	delay := min(retryBaseDelay<<min(attempt, 16), retryMaxDelay)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// stallTransport 读取响应体时长时间收不到数据则中止请求，防止停滞的服务器让 go2v 永远挂起
+type stallTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration // timeout 连续多久收不到数据视为停滞
+}
+
+// RoundTrip http.RoundTripper 接口方法
+func (t *stallTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancelCause(req.Context())
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel(nil)
+		return nil, err
+	}
+	body := &stallReader{ReadCloser: resp.Body, ctx: ctx, cancel: cancel, timeout: t.timeout}
+	body.timer = time.AfterFunc(t.timeout, func() { cancel(errStalled) })
+	resp.Body = body
+	return resp, nil
+}
+
+// stallReader 每次读到数据时重置停滞计时器的响应体
+type stallReader struct {
+	io.ReadCloser
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+// Read io.Reader 接口方法
+func (r *stallReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.timer.Reset(r.timeout)
+	}
+	if err != nil && errors.Is(context.Cause(r.ctx), errStalled) {
+		err = errStalled
+	}
+	return n, err
+}
+
+// Close io.Closer 接口方法
+func (r *stallReader) Close() error {
+	r.timer.Stop()
+	r.cancel(nil)
+	return r.ReadCloser.Close()
+}
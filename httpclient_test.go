@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// useHTTPRetries 在测试期间将 --retries 设为 n
+func useHTTPRetries(t *testing.T, n int) {
+	t.Helper()
+	old := httpRetries
+	httpRetries = n
+	t.Cleanup(func() { httpRetries = old })
+}
+
+// statusServer 总是返回 status 的服务器，返回请求计数器
+func statusServer(t *testing.T, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var count atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &count
+}
+
+func TestRetryTransportRetries(t *testing.T) {
+	useHTTPRetries(t, 2)
+	tests := []struct {
+		status int
+		want   int32 // want 服务器收到的请求数
+	}{
+		{http.StatusServiceUnavailable, 3},
+		{http.StatusBadGateway, 3},
+		{http.StatusTooManyRequests, 3},
+		{http.StatusNotImplemented, 1},
+		{http.StatusNotFound, 1},
+		{http.StatusOK, 1},
+	}
+	for _, tt := range tests {
+		srv, count := statusServer(t, tt.status)
+		client := &http.Client{Transport: &retryTransport{base: http.DefaultTransport}}
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("status %d: %v", tt.status, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("status %d: got final status %d", tt.status, resp.StatusCode)
+		}
+		if got := count.Load(); got != tt.want {
+			t.Errorf("status %d: server received %d requests, want %d", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestRetryTransportDisabled(t *testing.T) {
+	srv, count := statusServer(t, http.StatusServiceUnavailable)
+	client := &http.Client{Transport: &retryTransport{base: http.DefaultTransport}}
+
+	// withoutRetries 关闭单个请求的重试
+	useHTTPRetries(t, 3)
+	req, _ := http.NewRequestWithContext(withoutRetries(context.Background()), http.MethodGet, srv.URL, nil)
+	if resp, err := client.Do(req); err == nil {
+		resp.Body.Close()
+	}
+	if got := count.Load(); got != 1 {
+		t.Errorf("withoutRetries: server received %d requests, want 1", got)
+	}
+
+	// --retries 0 关闭所有重试
+	count.Store(0)
+	httpRetries = 0
+	if resp, err := client.Get(srv.URL); err == nil {
+		resp.Body.Close()
+	}
+	if got := count.Load(); got != 1 {
+		t.Errorf("--retries 0: server received %d requests, want 1", got)
+	}
+}
+
+func TestRetryTransportSkipsCertificateErrors(t *testing.T) {
+	useHTTPRetries(t, 3)
+	var conns atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	// 默认 Transport 不信任测试服务器的自签名证书
+	client := &http.Client{Transport: &retryTransport{base: http.DefaultTransport.(*http.Transport).Clone()}}
+	_, err := client.Get(srv.URL)
+	var certErr *tls.CertificateVerificationError
+	if !errors.As(err, &certErr) {
+		t.Fatalf("error = %v, want a certificate verification error", err)
+	}
+	if got := conns.Load(); got != 1 {
+		t.Errorf("server saw %d connections, want 1 (no retries)", got)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	withRetryAfter := func(v string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": {v}}}
+	}
+	if got := retryDelay(0, withRetryAfter("2")); got != 2*time.Second {
+		t.Errorf("Retry-After 2: delay = %s, want 2s", got)
+	}
+	if got := retryDelay(0, withRetryAfter("86400")); got != retryMaxDelay {
+		t.Errorf("Retry-After 86400: delay = %s, want the %s cap", got, retryMaxDelay)
+	}
+	for attempt, want := range map[int]time.Duration{0: retryBaseDelay, 2: 4 * retryBaseDelay, 100: retryMaxDelay} {
+		for i := 0; i < 20; i++ {
+			// 抖动使等待时间落在 [want/2, want] 区间内；无法解析的 Retry-After 被忽略
+			if got := retryDelay(attempt, withRetryAfter("soon")); got < want/2 || got > want {
+				t.Errorf("attempt %d: delay = %s, want between %s and %s", attempt, got, want/2, want)
+			}
+		}
+	}
+}
+
+func TestStallTransport(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		http.NewResponseController(w).Flush()
+		// 发送部分数据后停止，直到请求结束
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &stallTransport{base: http.DefaultTransport, timeout: 50 * time.Millisecond}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	start := time.Now()
+	data, err := io.ReadAll(resp.Body)
+	if !errors.Is(err, errStalled) {
+		t.Fatalf("error = %v, want errStalled", err)
+	}
+	if string(data) != "partial" {
+		t.Errorf("read %q before stalling, want %q", data, "partial")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("stall detected after %s", elapsed)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// stagingDirPrefix 解压暂存目录的名称前缀，位于安装路径的同级目录，后接安装目录名和随机后缀
+	stagingDirPrefix = ".go2v-staging-"
+	// backupDirSuffix 替换期间保留旧安装目录时使用的后缀
+	backupDirSuffix = ".go2v-backup"
+	// installLockPrefix 安装锁文件的名称前缀，位于安装路径的同级目录，后接安装目录名
+	installLockPrefix = ".go2v-install-"
+)
+
+// errExchangeUnsupported 当前平台或文件系统不支持原子交换两个路径
+var errExchangeUnsupported = errors.New("atomic exchange not supported")
+
+// installGoRoot 以原子替换的方式将归档安装到 installPath
+// 先解压到同级暂存目录并做完整性检查，再替换旧目录；任何步骤失败都会保留或恢复旧版本
+// 同一版本的安装通过锁文件串行执行，避免互相清理对方的暂存目录
+func installGoRoot(archivePath, installPath, version string) (err error) {
+	installPath = filepath.Clean(installPath)
+	parentDir := filepath.Dir(installPath)
+	backupPath := installPath + backupDirSuffix
+
+	if err := os.MkdirAll(parentDir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", parentDir, err)
+	}
+
+	unlock, err := lockFile(filepath.Join(parentDir, installLockPrefix+filepath.Base(installPath)+".lock"))
+	if err != nil {
+		return fmt.Errorf("failed to lock %s: %w", installPath, err)
+	}
+	defer unlock()
+
+	// 处理上次被中断的安装留下的状态
+	if err := recoverInterruptedInstall(installPath, backupPath); err != nil {
+		return err
+	}
+
+	// 暂存目录与安装路径位于同一目录，确保 rename 不会跨文件系统
+	stagingDir, err := os.MkdirTemp(parentDir, stagingDirPattern(installPath))
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory in %s: %w", parentDir, err)
+	}
+	defer func() {
+		debugPrint("Removing staging directory: %s", stagingDir)
+		if removeErr := os.RemoveAll(stagingDir); removeErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to remove staging directory %s: %v\n", stagingDir, removeErr)
+		}
+	}()
+
+	fmt.Printf("Extracting installation package to staging directory %s...\n", stagingDir)
+	extractStart := time.Now()
+	if err := extractTarGz(archivePath, stagingDir); err != nil {
+		return fmt.Errorf("failed to extract installation package: %w", err)
+	}
+	fmt.Printf("Extraction complete in %s\n", time.Since(extractStart).Truncate(time.Millisecond))
+
+	// 官方归档的顶层目录为 go/
+	newRoot := filepath.Join(stagingDir, "go")
+	if err := verifyGoRoot(newRoot, version); err != nil {
+		return fmt.Errorf("extracted Go installation failed sanity check: %w", err)
+	}
+	debugPrint("Staged Go installation passed sanity check: %s", newRoot)
+
+	// 优先原子交换新旧目录，旧版本随后随暂存目录一起删除
+	if _, err := os.Lstat(installPath); err == nil {
+		err := exchangePaths(newRoot, installPath)
+		if err == nil {
+			debugPrint("Swapped %s into place, previous installation moved to %s", installPath, newRoot)
+			return nil
+		}
+		if !errors.Is(err, errExchangeUnsupported) {
+			return fmt.Errorf("failed to swap new installation into place: %w", err)
+		}
+		debugPrint("Atomic exchange not supported, replacing %s with two renames", installPath)
+	}
+
+	// 不支持原子交换时先将旧版本移到备份目录再放入新版本；两次 rename 之间 installPath 短暂不存在，
+	// 此时失败会立即恢复旧版本，进程被中断时由下次安装的 recoverInterruptedInstall 恢复
+	hadPrevious := false
+	if _, err := os.Lstat(installPath); err == nil {
+		debugPrint("Moving previous installation %s to %s", installPath, backupPath)
+		if err := os.Rename(installPath, backupPath); err != nil {
+			return fmt.Errorf("failed to move previous installation aside: %w", err)
+		}
+		hadPrevious = true
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to check installation path %s: %w", installPath, err)
+	}
+
+	if err := os.Rename(newRoot, installPath); err != nil {
+		if hadPrevious {
+			if restoreErr := os.Rename(backupPath, installPath); restoreErr != nil {
+				return fmt.Errorf("failed to move new installation into place: %w (restoring previous installation also failed: %v, it remains at %s)", err, restoreErr, backupPath)
+			}
+			fmt.Println("Previous installation restored")
+		}
+		return fmt.Errorf("failed to move new installation into place: %w", err)
+	}
+
+	if hadPrevious {
+		debugPrint("Removing previous installation: %s", backupPath)
+		if err := os.RemoveAll(backupPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to remove previous installation %s: %v\n", backupPath, err)
+		}
+	}
+	return nil
+}
+
+// stagingDirPattern 返回 installPath 对应的暂存目录名称模式
+// 暂存目录名包含版本目录名，清理时只匹配同一版本，不会误删其他版本正在进行的安装
+func stagingDirPattern(installPath string) string {
+	return stagingDirPrefix + filepath.Base(installPath) + "-*"
+}
+
+// recoverInterruptedInstall 恢复上次中断安装留下的备份目录，并清理同一版本遗留的暂存目录
+func recoverInterruptedInstall(installPath, backupPath string) error {
+	if _, err := os.Lstat(backupPath); err == nil {
+		if _, err := os.Lstat(installPath); os.IsNotExist(err) {
+			// 旧版本已移走但新版本未就位，恢复旧版本
+			fmt.Printf("Found previous installation left by an interrupted install, restoring %s...\n", installPath)
+			if err := os.Rename(backupPath, installPath); err != nil {
+				return fmt.Errorf("failed to restore previous installation from %s: %w", backupPath, err)
+			}
+		} else {
+			// 新版本已就位，备份只是未来得及删除
+			debugPrint("Removing stale backup directory: %s", backupPath)
+			if err := os.RemoveAll(backupPath); err != nil {
+				return fmt.Errorf("failed to remove stale backup %s: %w", backupPath, err)
+			}
+		}
+	}
+
+	stale, err := filepath.Glob(filepath.Join(filepath.Dir(installPath), stagingDirPattern(installPath)))
+	if err != nil {
+		return err
+	}
+	for _, dir := range stale {
+		debugPrint("Removing stale staging directory: %s", dir)
+		if err := os.RemoveAll(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to remove stale staging directory %s: %v\n", dir, err)
+		}
+	}
+	return nil
+}
+
+// verifyGoRoot 检查解压出的 GOROOT 是否完整：go 可执行文件存在，VERSION 文件与期望版本一致
+func verifyGoRoot(goRoot, version string) error {
+	goBin := filepath.Join(goRoot, "bin", "go")
+	fi, err := os.Stat(goBin)
+	if err != nil {
+		return fmt.Errorf("missing %s: %w", goBin, err)
+	}
+	if !fi.Mode().IsRegular() || fi.Mode().Perm()&0111 == 0 {
+		return fmt.Errorf("%s is not an executable file", goBin)
+	}
+
+	for _, dir := range []string{"src", "pkg"} {
+		if fi, err := os.Stat(filepath.Join(goRoot, dir)); err != nil || !fi.IsDir() {
+			return fmt.Errorf("missing %s directory in %s", dir, goRoot)
+		}
+	}
+
+	installed, err := readGoRootVersion(goRoot)
+	if err != nil {
+		return err
+	}
+	if version != "" && installed != "go"+version {
+		return fmt.Errorf("VERSION file reports %s, expected go%s", installed, version)
+	}
+	return nil
+}
+
+// readGoRootVersion 读取 GOROOT 中 VERSION 文件的第一行 (例如 "go1.22.2")
+func readGoRootVersion(goRoot string) (string, error) {
+	versionFile := filepath.Join(goRoot, "VERSION")
+	f, err := os.Open(versionFile)
+	if err != nil {
+		return "", fmt.Errorf("missing %s: %w", versionFile, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		return "", fmt.Errorf("empty VERSION file %s", versionFile)
+	}
+	return strings.TrimSpace(scanner.Text()), nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecoverInterruptedInstallKeepsOtherVersions(t *testing.T) {
+	store := &toolchainStore{Root: t.TempDir()}
+	installPath := store.VersionPath("1.22.2")
+	for _, dir := range []string{
+		stagingDirPrefix + "go1.22.2-111",  // 本版本上次中断的安装
+		stagingDirPrefix + "go1.23.0-222",  // 其他版本正在进行的安装
+		stagingDirPrefix + "go1.22.20-333", // 前缀相同的其他版本
+	} {
+		if err := os.MkdirAll(filepath.Join(store.VersionsDir(), dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := recoverInterruptedInstall(installPath, installPath+backupDirSuffix); err != nil {
+		t.Fatal(err)
+	}
+	left, _ := filepath.Glob(filepath.Join(store.VersionsDir(), stagingDirPrefix+"*"))
+	want := []string{
+		filepath.Join(store.VersionsDir(), stagingDirPrefix+"go1.22.20-333"),
+		filepath.Join(store.VersionsDir(), stagingDirPrefix+"go1.23.0-222"),
+	}
+	if !reflect.DeepEqual(left, want) {
+		t.Errorf("remaining staging dirs = %v, want %v", left, want)
+	}
+}
+
+func TestInstalledSkipsStagingAndBackupDirs(t *testing.T) {
+	store := &toolchainStore{Root: t.TempDir()}
+	for _, dir := range []string{
+		"go1.22.2",
+		"go1.23.0",
+		"go1.22.2" + backupDirSuffix,
+		stagingDirPrefix + "go1.24.0-123",
+	} {
+		if err := os.MkdirAll(filepath.Join(store.VersionsDir(), dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := store.Installed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"1.23.0", "1.22.2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Installed() = %v, want %v", got, want)
+	}
+}
+
+// testGoRootEntries 返回能通过 verifyGoRoot 检查的最小 Go 归档条目
+func testGoRootEntries(version, marker string) []testTarEntry {
+	return []testTarEntry{
+		{Header: tar.Header{Name: "go/bin/go", Typeflag: tar.TypeReg, Mode: 0755, Size: 2}, Body: "go"},
+		{Header: tar.Header{Name: "go/src/", Typeflag: tar.TypeDir, Mode: 0755}},
+		{Header: tar.Header{Name: "go/pkg/", Typeflag: tar.TypeDir, Mode: 0755}},
+		testFile("go/VERSION", "go"+version+"\n"),
+		testFile("go/marker", marker),
+	}
+}
+
+// checkInstallLeftovers 确认安装路径旁没有残留暂存目录和备份目录
+func checkInstallLeftovers(t *testing.T, installPath string) {
+	t.Helper()
+	for _, pattern := range []string{stagingDirPrefix + "*", "*" + backupDirSuffix} {
+		if left, _ := filepath.Glob(filepath.Join(filepath.Dir(installPath), pattern)); len(left) > 0 {
+			t.Errorf("left behind: %v", left)
+		}
+	}
+}
+
+func TestInstallGoRootReplacesPrevious(t *testing.T) {
+	dir := t.TempDir()
+	installPath := filepath.Join(dir, "versions", "go1.22.2")
+	if err := installGoRoot(writeTestTarGz(t, dir, testGoRootEntries("1.22.2", "old")), installPath, "1.22.2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := installGoRoot(writeTestTarGz(t, dir, testGoRootEntries("1.22.2", "new")), installPath, "1.22.2"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(installPath, "marker")); err != nil || string(data) != "new" {
+		t.Errorf("marker = %q, %v; want the new installation", data, err)
+	}
+	checkInstallLeftovers(t, installPath)
+}
+
+func TestInstallGoRootKeepsPreviousOnFailedCheck(t *testing.T) {
+	dir := t.TempDir()
+	installPath := filepath.Join(dir, "versions", "go1.22.2")
+	if err := installGoRoot(writeTestTarGz(t, dir, testGoRootEntries("1.22.2", "old")), installPath, "1.22.2"); err != nil {
+		t.Fatal(err)
+	}
+
+	// VERSION 与期望版本不一致，完整性检查失败
+	err := installGoRoot(writeTestTarGz(t, dir, testGoRootEntries("1.22.3", "new")), installPath, "1.22.2")
+	if err == nil || !strings.Contains(err.Error(), "sanity check") {
+		t.Fatalf("error = %v, want a failed sanity check", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(installPath, "marker")); err != nil || string(data) != "old" {
+		t.Errorf("marker = %q, %v; want the previous installation intact", data, err)
+	}
+	checkInstallLeftovers(t, installPath)
+}
+
+func TestInstallLockWaitsForHolder(t *testing.T) {
+	if !fileLockSupported {
+		t.Skip("file locking is not supported on this OS")
+	}
+	path := filepath.Join(t.TempDir(), installLockPrefix+"go1.22.2.lock")
+	unlock, err := lockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan func())
+	go func() {
+		unlock2, err := lockFile(path)
+		if err != nil {
+			t.Error(err)
+			unlock2 = func() {}
+		}
+		acquired <- unlock2
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("second lockFile returned while the lock was held")
+	case <-time.After(100 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case unlock2 := <-acquired:
+		unlock2()
+	case <-time.After(5 * time.Second):
+		t.Fatal("second lockFile did not return after the lock was released")
+	}
+}
@@ -0,0 +1,35 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	// atFDCWD 对应 AT_FDCWD，表示相对当前工作目录解析路径
+	atFDCWD = -0x64
+	// atSymlinkNoFollow 对应 AT_SYMLINK_NOFOLLOW，表示不跟随符号链接
+	atSymlinkNoFollow = 0x100
+)
+
+// lchtimes 修改符号链接自身 (而非其指向目标) 的访问时间和修改时间 (Linux specific)
+func lchtimes(path string, atime, mtime time.Time) error {
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return err
+	}
+	ts := [2]syscall.Timespec{
+		syscall.NsecToTimespec(atime.UnixNano()),
+		syscall.NsecToTimespec(mtime.UnixNano()),
+	}
+	dirfd := atFDCWD
+	_, _, errno := syscall.Syscall6(syscall.SYS_UTIMENSAT, uintptr(dirfd), uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(&ts)), atSymlinkNoFollow, 0, 0)
+	if errno != 0 {
+		return &os.PathError{Op: "lchtimes", Path: path, Err: errno}
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package main
+
+import "time"
+
+// lchtimes 修改符号链接自身的时间戳 (Fallback for other OS)
+// 标准库未提供不跟随符号链接的时间修改接口，此处直接跳过
+func lchtimes(path string, atime, mtime time.Time) error {
+	debugPrint("Setting symlink times is not supported on this OS, skipping %s", path)
+	return nil
+}
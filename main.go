@@ -1,23 +1,23 @@
 package main
 
 import (
-	"archive/tar"
-	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
-	"flag"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strings"
+	"sync"
 	"time"
 )
 
 const (
-	// goVersionURL Go 官方下载页面 JSON API 的 URL，获取所有 Go 版本信息
-	goVersionURL = "https://go.dev/dl/?mode=json"
+	// goDevBaseURL Go 官方下载页面，JSON API 和归档文件都位于该地址下
+	goDevBaseURL = "https://go.dev/dl/"
 	// latestVersionTextURL Go 官方提供最新版本号的纯文本 URL
 	latestVersionTextURL = "https://go.dev/VERSION?m=text"
 	// systemProfileDDirextory 系统全局 PATH 配置目录
@@ -28,16 +28,29 @@ const (
 
 // GoVersionInfo 表示 Go 版本信息 JSON 响应中的单个版本条目
 type GoVersionInfo struct {
-	Version string     `json:"version"` // Version Go 版本号 (例如 "go1.22.2")
-	Stable  bool       `json:"stable"`  // Stable 表明是否是稳定版本
-	Files   []struct { // Files 该版本对应的文件列表
-		Filename string `json:"filename"` // Filename 文件名 (例如 "go1.22.2.linux-amd64.tar.gz")
-		OS       string `json:"os"`       // OS 操作系统 (例如 "linux", "darwin", "windows")
-		Arch     string `json:"arch"`     // Arch 架构 (例如 "amd64", "arm64")
-		Checksum string `json:"checksum"` // Checksum 文件的校验和
-		Size     int    `json:"size"`     // Size 文件大小
-		Kind     string `json:"kind"`     // Kind 文件类型 (例如 "archive", "pkg")
-	} `json:"files"`
+	Version string       `json:"version"` // Version Go 版本号 (例如 "go1.22.2")
+	Stable  bool         `json:"stable"`  // Stable 表明是否是稳定版本
+	Files   []GoFileInfo `json:"files"`   // Files 该版本对应的文件列表
+}
+
+// GoFileInfo 表示某个 Go 版本下的单个可下载文件
+type GoFileInfo struct {
+	Filename string `json:"filename"` // Filename 文件名 (例如 "go1.22.2.linux-amd64.tar.gz")
+	OS       string `json:"os"`       // OS 操作系统 (例如 "linux", "darwin", "windows")
+	Arch     string `json:"arch"`     // Arch 架构 (例如 "amd64", "arm64")
+	Checksum string `json:"sha256"`   // Checksum 文件的 SHA-256 校验和 (十六进制)
+	Size     int    `json:"size"`     // Size 文件大小
+	Kind     string `json:"kind"`     // Kind 文件类型 (例如 "archive", "pkg")
+}
+
+// archiveFor 返回该版本适用于指定 OS 和架构的归档文件
+func (v GoVersionInfo) archiveFor(goos, goarch string) (GoFileInfo, bool) {
+	for _, file := range v.Files {
+		if file.OS == goos && file.Arch == goarch && file.Kind == "archive" {
+			return file, true
+		}
+	}
+	return GoFileInfo{}, false
 }
 
 var (
@@ -47,8 +60,34 @@ var (
 	debugMode bool
 	// rootMode 控制是否尝试以 root 权限进行全局 PATH 配置
 	rootMode bool
+	// insecureSkipVerify 在无法获取校验和时允许跳过完整性校验
+	insecureSkipVerify bool
+	// verifySignature 控制是否验证归档的 OpenPGP 签名
+	verifySignature bool
+	// gpgKeyringPath 用于验证签名的公钥环文件路径，为空时使用内嵌的 Go 发布签名公钥 (构建中未内嵌时必须指定)
+	gpgKeyringPath string
+	// extractWorkers 解压时并行写入文件的协程数，1 表示顺序写入
+	extractWorkers int
+	// downloadSegments 下载归档时并发请求的分段数，1 表示单连接下载
+	downloadSegments int
+	// allowUnstable 解析版本时是否允许 rc/beta 等预发布版本
+	allowUnstable bool
+	// metadataTTL 发布元数据缓存的有效期，过期后向服务器重新验证
+	metadataTTL time.Duration
+	// refreshMetadata 忽略缓存，强制重新下载发布元数据
+	refreshMetadata bool
+	// offlineMode 只使用缓存的发布元数据和归档，不访问网络
+	offlineMode bool
 )
 
+// errOffline 在 --offline 模式下尝试访问网络时返回
+var errOffline = errors.New("network access disabled by --offline")
+
+// isLocalURL 判断 URL 是否指向本地文件，访问本地文件不受 --offline 限制
+func isLocalURL(u string) bool {
+	return strings.HasPrefix(u, "file://")
+}
+
 // listArgs 自定义的 flag 类型，接收多个 -v 参数
 type listArgs []string
 
@@ -63,16 +102,6 @@ func (l *listArgs) Set(value string) error {
 	return nil
 }
 
-// init 函数在 main 之前执行，初始化命令行 flag
-func init() {
-	// 注册 -v flag
-	flag.Var(&targetVersions, "v", "Specify the Go version to install (e.g., 1.22.2, 1.23). Can be specified multiple times.")
-	// 注册 --debug flag
-	flag.BoolVar(&debugMode, "debug", false, "Enable debug mode for verbose output.")
-	// 注册 --root flag
-	flag.BoolVar(&rootMode, "root", false, "Attempt to configure PATH globally with root privileges.")
-}
-
 // debugPrint 在调试模式下打印信息
 func debugPrint(format string, a ...interface{}) {
 	if debugMode {
@@ -82,269 +111,16 @@ func debugPrint(format string, a ...interface{}) {
 
 // main 函数程序入口点
 func main() {
-	// 解析命令行参数
-	flag.Parse()
-
-	debugPrint("Debug mode enabled")
-
-	fmt.Println("Starting GO environment installation (rootless by default)")
-
-	// 获取系统信息（内核版本和架构）
-	kernelVersion, detectedArchitecture, err := getSystemInfo()
-	var goArch string
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to get system information: %v\n", err)
-		fmt.Printf("Warning: Will use Go's build time system and architecture (%s/%s)\n", runtime.GOOS, runtime.GOARCH)
-		goArch = runtime.GOARCH
-	} else {
-		fmt.Printf("System Info: Kernel Version %s, Detected Architecture %s\n", kernelVersion, detectedArchitecture)
-		goArch = mapArchitecture(detectedArchitecture)
-		if goArch == "" {
-			fmt.Fprintf(os.Stderr, "Error: Could not map detected architecture '%s' to a supported Go architecture.\n", detectedArchitecture)
-			os.Exit(1)
-		}
-		fmt.Printf("Mapped Go Architecture: %s\n", goArch)
-	}
-
-	// 获取当前用户主目录路径
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to get user home directory: %v\n", err)
-		os.Exit(1)
-	}
-	// installPath Go 安装路径（用户主目录下的 .local/go）
-	installPath := filepath.Join(homeDir, ".local", "go")
-	fmt.Printf("Installation path set to: %s\n", installPath)
-
-	// 获取所有 Go 版本信息列表（从 JSON API）
-	debugPrint("Fetching all Go version information from JSON API...")
-	allVersions, err := getAllGoVersions()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to get Go version list from JSON API: %v\n", err)
-	}
-	if allVersions != nil {
-		debugPrint("Fetched %d Go versions from JSON API", len(allVersions))
-	}
-
-	// versionToInstall 最终确定的版本号
-	// downloadURL 最终确定的下载 URL
-	var versionToInstall, downloadURL string
-	foundDownloadable := false
-
-	if len(targetVersions) > 0 {
-		debugPrint("Target versions specified: %v", targetVersions)
-		for _, targetVer := range targetVersions {
-			originalTargetVer := targetVer
-			// 补全简略版本号
-			if count := strings.Count(targetVer, "."); count == 1 {
-				targetVer = targetVer + ".0"
-				debugPrint("Appended .0 to version: %s -> %s", originalTargetVer, targetVer)
-			}
-
-			// 在 JSON API 数据中查找匹配版本
-			if allVersions != nil {
-				for _, v := range allVersions {
-					if strings.TrimPrefix(v.Version, "go") == targetVer {
-						debugPrint("Found matching version in JSON list: %s", v.Version)
-						// 查找适用于当前 OS 和架构的 archive 文件
-						for _, file := range v.Files {
-							if file.OS == runtime.GOOS && file.Arch == goArch && file.Kind == "archive" {
-								versionToInstall = strings.TrimPrefix(v.Version, "go")
-								downloadURL = fmt.Sprintf("https://go.dev/dl/%s", file.Filename)
-								foundDownloadable = true
-								debugPrint("Found matching download file for %s/%s: %s", runtime.GOOS, goArch, file.Filename)
-								break
-							} else {
-								debugPrint("Skipping file %s (OS: %s, Arch: %s), expected %s/%s", file.Filename, file.OS, file.Arch, runtime.GOOS, goArch)
-							}
-						}
-					}
-					if foundDownloadable {
-						break
-					}
-				}
-			}
-
-			if foundDownloadable {
-				break
-			} else {
-				fmt.Printf("Warning: Could not find specified version %s (%s/%s) in JSON API. Attempting to construct URL...\n", originalTargetVer, runtime.GOOS, goArch)
-				versionToInstall = targetVer
-				downloadURL = fmt.Sprintf("https://go.dev/dl/go%s.%s-%s.tar.gz", versionToInstall, runtime.GOOS, goArch)
-				fmt.Printf("Attempting to construct download URL: %s\n", downloadURL)
-				foundDownloadable = true
-				break
-			}
-		}
-
-		if !foundDownloadable {
-			fmt.Fprintf(os.Stderr, "Error: No matching Go version found for installation. Please check the version number and system architecture.\n")
-			os.Exit(1)
-		}
-
-	} else {
-		// 未指定版本，查找最新稳定版本
-		debugPrint("No target version specified")
-
-		// 优先从 JSON API 获取最新稳定版本
-		if allVersions != nil {
-			debugPrint("Looking for latest stable version in JSON API")
-			for _, v := range allVersions {
-				if v.Stable {
-					debugPrint("Checking stable version: %s", v.Version)
-					// 查找适用于当前 OS 和架构的 archive 文件
-					for _, file := range v.Files {
-						if file.OS == runtime.GOOS && file.Arch == goArch && file.Kind == "archive" {
-							versionToInstall = strings.TrimPrefix(v.Version, "go")
-							downloadURL = fmt.Sprintf("https://go.dev/dl/%s", file.Filename)
-							foundDownloadable = true
-							debugPrint("Found latest stable download file for %s/%s: %s", runtime.GOOS, goArch, file.Filename)
-							break
-						} else {
-							debugPrint("Skipping file %s (OS: %s, Arch: %s), expected %s/%s", file.Filename, file.OS, file.Arch, runtime.GOOS, goArch)
-						}
-					}
-				} else {
-					debugPrint("Skipping non-stable version: %s", v.Version)
-				}
-				if foundDownloadable {
-					break
-				}
-			}
-		}
-
-		// 如果 JSON API 没找到，尝试从文本接口获取最新版本号
-		if !foundDownloadable || downloadURL == "" {
-			fmt.Println("Warning: Could not find latest stable version in JSON API. Attempting to get latest version from go.dev/VERSION?m=text using HTTP request...")
-			latestVer, err := getLatestGoVersionFromTextHTTP()
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: Failed to get latest Go version from text URL via HTTP: %v\n", err)
-				fmt.Fprintf(os.Stderr, "Error: Could not determine Go version to install.\n")
-				os.Exit(1)
-			}
-			versionToInstall = latestVer
-			downloadURL = fmt.Sprintf("https://go.dev/dl/go%s.%s-%s.tar.gz", versionToInstall, runtime.GOOS, goArch)
-			fmt.Printf("Deduced latest version: %s, Constructed download URL: %s\n", versionToInstall, downloadURL)
-			foundDownloadable = true
-		}
-
-		if !foundDownloadable {
-			fmt.Fprintf(os.Stderr, "Error: Internal error: Failed to determine version and download URL.\n")
-			os.Exit(1)
-		}
-		fmt.Printf("No version specified, installing latest stable version: %s\n", versionToInstall)
-	}
-
-	fmt.Printf("Confirmed download URL: %s\n", downloadURL)
-
-	// 下载 Go 安装包
-	fmt.Printf("Downloading installation package...\n")
-	downloadFileName := filepath.Base(downloadURL)
-	downloadFilePath := filepath.Join(os.TempDir(), downloadFileName)
-
-	debugPrint("Download file name: %s", downloadFileName)
-	debugPrint("Download file path: %s", downloadFilePath)
-
-	if downloadFileName == "." || downloadFileName == "" {
-		fmt.Fprintf(os.Stderr, "Error: Invalid download URL or file name extraction failed. Download URL: %s\n", downloadURL)
-		os.Exit(1)
-	}
-
-	// 检查临时目录
-	tempDir := os.TempDir()
-	debugPrint("Checking temporary directory: %s", tempDir)
-
-	if _, err := os.Stat(tempDir); os.IsNotExist(err) {
-		debugPrint("Temporary directory does not exist, creating...")
-		if err := os.MkdirAll(tempDir, 0755); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: Failed to create temporary directory: %v\n", err)
-			os.Exit(1)
-		}
-	} else if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to check temporary directory: %v\n", err)
-		os.Exit(1)
-	}
-
-	// 检查临时目录是否可写
-	testFile := filepath.Join(tempDir, "test_write")
-	debugPrint("Checking write permissions in temporary directory: %s", testFile)
-	if err := os.WriteFile(testFile, []byte("test"), 0644); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Temporary directory %s is not writable. Please check permissions.\n", tempDir)
-		os.Exit(1)
-	}
-	os.Remove(testFile)
-	debugPrint("Temporary directory is writable")
-
-	// 执行文件下载
-	err = downloadFile(downloadURL, downloadFilePath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to download installation package: %v\n", err)
-		os.Exit(1)
-	}
-	fmt.Printf("Installation package downloaded successfully: %s\n", downloadFilePath)
-
-	// 清理旧的 Go 安装目录
-	fmt.Printf("Cleaning up old installation directory (if exists)....\n")
-	cleanupPath := installPath
-
-	// 如果设置了 --root flag 且具有 root 权限，则清理 /usr/local/go
-	if rootMode && os.Geteuid() == 0 {
-		cleanupPath = "/usr/local/go"
-		installPath = "/usr/local/go"
-		debugPrint("Root mode enabled and has root privileges. Cleaning up global installation path: %s", cleanupPath)
-		debugPrint("Setting global installation path to: %s", installPath)
-	} else {
-		debugPrint("Cleaning up user installation path: %s", cleanupPath)
-	}
-
-	debugPrint("Checking installation path for cleanup: %s", cleanupPath)
-	if _, err := os.Stat(cleanupPath); !os.IsNotExist(err) {
-		debugPrint("Old installation directory found, removing...")
-		err = os.RemoveAll(cleanupPath)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: Failed to clean up old installation directory: %v\n", err)
-		} else {
-			debugPrint("Old installation directory cleaned up")
-		}
-	} else {
-		debugPrint("Old installation directory not found, skipping cleanup")
-	}
-
-	// 解压 Go 安装包
-	fmt.Printf("Extracting installation package to %s...\n", installPath)
-	extractDestDir := filepath.Join(homeDir, ".local")
-
-	// 如果设置了 --root flag 且具有 root 权限，则解压到 /usr/local
-	if rootMode && os.Geteuid() == 0 {
-		extractDestDir = "/usr/local"
-		debugPrint("Root mode enabled and has root privileges. Extracting to global directory: %s", extractDestDir)
-	} else {
-		debugPrint("Extracting to user directory: %s", extractDestDir)
-	}
-
-	debugPrint("Extracting %s to %s", downloadFilePath, extractDestDir)
-	err = extractTarGz(downloadFilePath, extractDestDir)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to extract installation package: %v\n", err)
-		os.Exit(1)
-	}
-	fmt.Printf("Extraction complete\n")
-
-	// 清理下载的 Go 安装包文件
-	fmt.Printf("Cleaning up downloaded installation package...\n")
-	debugPrint("Removing downloaded file: %s", downloadFilePath)
-	err = os.Remove(downloadFilePath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Failed to clean up installation package: %v\n", err)
-	} else {
-		fmt.Printf("Installation package cleaned up\n")
-	}
+	os.Exit(runCLI(os.Args[1:]))
+}
 
-	// 配置 PATH 环境变量
-	goBinPath := filepath.Join(installPath, "bin")
+// configurePath 配置 PATH 环境变量，使其包含 activePath/bin
+// global 为 true 时写入 /etc/profile.d/go.sh，失败时回退到用户配置
+func configurePath(homeDir, activePath string, global bool) {
+	goBinPath := filepath.Join(activePath, "bin")
 
 	// 检查是否在 root 模式下并且具有 root 权限
-	if rootMode && os.Geteuid() == 0 {
+	if global {
 		fmt.Println("Attempting to configure PATH globally...")
 		systemGoProfilePath := filepath.Join(systemProfileDDirextory, systemGoProfileFilename)
 		exportLine := fmt.Sprintf("export PATH=\"%s:$PATH\"", goBinPath)
@@ -353,11 +129,11 @@ func main() {
 		if _, err := os.Stat(systemProfileDDirextory); os.IsNotExist(err) {
 			fmt.Fprintf(os.Stderr, "Error: Directory %s does not exist. Cannot configure PATH globally.\n", systemProfileDDirextory)
 			fmt.Println("Falling back to user configuration...")
-			configureUserPath(homeDir, installPath)
+			configureUserPath(homeDir, activePath)
 		} else if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: Failed to check directory %s: %v\n", systemProfileDDirextory, err)
 			fmt.Println("Falling back to user configuration...")
-			configureUserPath(homeDir, installPath)
+			configureUserPath(homeDir, activePath)
 		} else {
 			// 检查 /etc/profile.d/go.sh 是否存在
 			_, err := os.Stat(systemGoProfilePath)
@@ -368,14 +144,14 @@ func main() {
 				if createErr != nil {
 					fmt.Fprintf(os.Stderr, "Warning: Failed to create %s: %v\n", systemGoProfilePath, createErr)
 					fmt.Println("Falling back to user configuration...")
-					configureUserPath(homeDir, installPath)
+					configureUserPath(homeDir, activePath)
 				} else {
 					defer file.Close()
 					_, writeErr := file.WriteString(exportLine + "\n")
 					if writeErr != nil {
 						fmt.Fprintf(os.Stderr, "Warning: Failed to write to %s: %v\n", systemGoProfilePath, writeErr)
 						fmt.Println("Falling back to user configuration...")
-						configureUserPath(homeDir, installPath)
+						configureUserPath(homeDir, activePath)
 					} else {
 						fmt.Printf("Added '%s' to %s.\n", exportLine, systemGoProfilePath)
 						printGlobalActivationInstruction(systemGoProfilePath)
@@ -384,14 +160,14 @@ func main() {
 			} else if err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: Failed to check %s: %v\n", systemGoProfilePath, err)
 				fmt.Println("Falling back to user configuration...")
-				configureUserPath(homeDir, installPath)
+				configureUserPath(homeDir, activePath)
 			} else {
 				// 如果文件存在，检查是否已包含 Go 的 PATH
 				content, readErr := os.ReadFile(systemGoProfilePath)
 				if readErr != nil {
 					fmt.Fprintf(os.Stderr, "Warning: Failed to read %s: %v\n", systemGoProfilePath, readErr)
 					fmt.Println("Falling back to user configuration...")
-					configureUserPath(homeDir, installPath)
+					configureUserPath(homeDir, activePath)
 				} else {
 					if strings.Contains(string(content), goBinPath) {
 						fmt.Printf("%s already contains Go bin directory in PATH. Skipping modification.\n", systemGoProfilePath)
@@ -402,14 +178,14 @@ func main() {
 						if openErr != nil {
 							fmt.Fprintf(os.Stderr, "Warning: Failed to open %s for appending: %v\n", systemGoProfilePath, openErr)
 							fmt.Println("Falling back to user configuration...")
-							configureUserPath(homeDir, installPath)
+							configureUserPath(homeDir, activePath)
 						} else {
 							defer file.Close()
 							_, writeErr := file.WriteString("\n" + exportLine + "\n")
 							if writeErr != nil {
 								fmt.Fprintf(os.Stderr, "Warning: Failed to write to %s: %v\n", systemGoProfilePath, writeErr)
 								fmt.Println("Falling back to user configuration...")
-								configureUserPath(homeDir, installPath)
+								configureUserPath(homeDir, activePath)
 							} else {
 								fmt.Printf("Appended '%s' to %s.\n", exportLine, systemGoProfilePath)
 								printGlobalActivationInstruction(systemGoProfilePath)
@@ -426,12 +202,8 @@ func main() {
 		} else {
 			fmt.Println("Configuring PATH for current user...")
 		}
-		configureUserPath(homeDir, installPath)
+		configureUserPath(homeDir, activePath)
 	}
-
-	// 最终安装成功提示
-	fmt.Println("\nGo environment installation complete")
-	fmt.Printf("Installed version: %s\n", versionToInstall)
 }
 
 // configureUserPath 配置用户主目录下的 PATH 环境变量
@@ -591,55 +363,164 @@ func bytesToString(bs []int8) []byte {
 	return b
 }
 
-// getAllGoVersions 获取所有 Go 版本信息列表 (从 go.dev/dl/?mode=json JSON API)
-func getAllGoVersions() ([]GoVersionInfo, error) {
-	resp, err := http.Get(goVersionURL)
+// getAllGoVersions 从配置的来源 (--source，默认为 go.dev) 获取所有 Go 版本信息列表
+// includeAll 为 true 时同时获取预发布版本和已归档的旧版本
+func getAllGoVersions(includeAll bool) ([]GoVersionInfo, error) {
+	source, err := releaseSource()
+	if err != nil {
+		return nil, err
+	}
+	return source.ListReleases(includeAll)
+}
+
+// fetchReleaseList 获取 JSON API 格式的版本列表
+// 结果缓存在缓存目录中：有效期 (--cache-ttl) 内直接使用缓存，过期后通过 ETag / Last-Modified
+// 条件请求重新验证，--refresh 强制重新下载；网络不可用时回退到过期的缓存
+// supersetURL 为包含 versionURL 全部内容的列表地址，离线时 versionURL 没有缓存则使用它的缓存
+func fetchReleaseList(versionURL, supersetURL string) ([]GoVersionInfo, error) {
+	cachePath, err := metadataCachePath(versionURL)
 	if err != nil {
-		return nil, fmt.Errorf("unable to fetch version info from %s: %w", goVersionURL, err)
+		debugPrint("Release metadata cache disabled: %v", err)
+	}
+	var cached *metadataCacheEntry
+	if cachePath != "" {
+		if cached = loadMetadataCache(cachePath); cached != nil && cached.URL != versionURL {
+			debugPrint("Ignoring metadata cache for %s", cached.URL)
+			cached = nil
+		}
+	}
+	// 离线模式只使用缓存，不检查有效期；完整列表是当前受支持版本列表的超集，也可以使用
+	if offlineMode {
+		if cached == nil && supersetURL != "" && supersetURL != versionURL {
+			if allPath, err := metadataCachePath(supersetURL); err == nil {
+				cachePath, cached = allPath, loadMetadataCache(allPath)
+			}
+		}
+		if cached == nil {
+			return nil, fmt.Errorf("no cached release metadata available, run go2v once with network access to populate the cache: %w", errOffline)
+		}
+		debugPrint("Offline: using cached release metadata from %s (fetched %s)", cachePath, cached.FetchedAt.Format(time.RFC3339))
+		return parseGoVersionList(cached.Body, cachePath)
+	}
+	if cached != nil && !refreshMetadata && cached.Age() < metadataTTL {
+		if versions, err := parseGoVersionList(cached.Body, cachePath); err == nil {
+			debugPrint("Using cached release metadata from %s (age %s)", cachePath, cached.Age().Round(time.Second))
+			return versions, nil
+		}
+		cached = nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, versionURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil && !refreshMetadata {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := httpClient.Do(req)
+	// 服务器出错 (例如重试后仍为 5xx) 与网络不可用一样，有缓存时回退到缓存
+	if err == nil && cached != nil && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotModified {
+		resp.Body.Close()
+		err = fmt.Errorf("status code %d", resp.StatusCode)
+	}
+	if err != nil {
+		if cached != nil {
+			if versions, parseErr := parseGoVersionList(cached.Body, cachePath); parseErr == nil {
+				fmt.Fprintf(os.Stderr, "Warning: Unable to fetch %s (%v), using cached release metadata from %s\n", versionURL, err, cached.FetchedAt.Local().Format(time.RFC1123))
+				return versions, nil
+			}
+		}
+		return nil, fmt.Errorf("unable to fetch version info from %s: %w", versionURL, err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to fetch version info from %s, status code: %d", goVersionURL, resp.StatusCode)
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		debugPrint("Release metadata not modified since %s", cached.FetchedAt.Format(time.RFC3339))
+		cached.FetchedAt = time.Now()
+		if etag := resp.Header.Get("ETag"); etag != "" {
+			cached.ETag = etag
+		}
+		if err := saveMetadataCache(cachePath, cached); err != nil {
+			debugPrint("Failed to update metadata cache %s: %v", cachePath, err)
+		}
+		return parseGoVersionList(cached.Body, cachePath)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("failed to fetch version info from %s, status code: %d", versionURL, resp.StatusCode)
 	}
 
-	var versions []GoVersionInfo
-	if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
-		return nil, fmt.Errorf("failed to parse version info from %s: %w", goVersionURL, err)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read version info from %s: %w", versionURL, err)
+	}
+	versions, err := parseGoVersionList(body, versionURL)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(versions) == 0 {
-		return nil, fmt.Errorf("no Go version info found in %s", goVersionURL)
+	if cachePath != "" {
+		entry := &metadataCacheEntry{
+			URL:          versionURL,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			FetchedAt:    time.Now(),
+			Body:         body,
+		}
+		if err := saveMetadataCache(cachePath, entry); err != nil {
+			debugPrint("Failed to write metadata cache %s: %v", cachePath, err)
+		} else {
+			debugPrint("Cached release metadata in %s", cachePath)
+		}
 	}
+	return versions, nil
+}
 
+// parseGoVersionList 解析 JSON API 返回的版本列表，source 用于错误信息
+func parseGoVersionList(data []byte, source string) ([]GoVersionInfo, error) {
+	var versions []GoVersionInfo
+	if err := json.Unmarshal(data, &versions); err != nil {
+		return nil, fmt.Errorf("failed to parse version info from %s: %w", source, err)
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no Go version info found in %s", source)
+	}
 	return versions, nil
 }
 
-// getLatestGoVersionFromTextHTTP 从 go.dev/VERSION?m=text 获取最新版本号 (使用 net/http)
-func getLatestGoVersionFromTextHTTP() (string, error) {
-	resp, err := http.Get(latestVersionTextURL)
+// fetchLatestVersionText 从 go.dev/VERSION?m=text 格式的纯文本接口获取最新版本号
+func fetchLatestVersionText(textURL string) (string, error) {
+	if offlineMode {
+		return "", fmt.Errorf("cannot fetch %s: %w", textURL, errOffline)
+	}
+	resp, err := httpClient.Get(textURL)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch latest version from %s via HTTP: %w", latestVersionTextURL, err)
+		return "", fmt.Errorf("failed to fetch latest version from %s via HTTP: %w", textURL, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to fetch latest version from %s via HTTP, status code: %d", latestVersionTextURL, resp.StatusCode)
+		return "", fmt.Errorf("failed to fetch latest version from %s via HTTP, status code: %d", textURL, resp.StatusCode)
 	}
 
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body from %s: %w", latestVersionTextURL, err)
+		return "", fmt.Errorf("failed to read response body from %s: %w", textURL, err)
 	}
 
 	lines := strings.Split(strings.TrimSpace(string(bodyBytes)), "\n")
 	if len(lines) < 1 {
-		return "", fmt.Errorf("unexpected output format from %s via HTTP", latestVersionTextURL)
+		return "", fmt.Errorf("unexpected output format from %s via HTTP", textURL)
 	}
 
 	versionLine := lines[0]
 	if !strings.HasPrefix(versionLine, "go") {
-		return "", fmt.Errorf("unexpected version format in output from %s via HTTP: %s", latestVersionTextURL, versionLine)
+		return "", fmt.Errorf("unexpected version format in output from %s via HTTP: %s", textURL, versionLine)
 	}
 
 	version := strings.TrimPrefix(versionLine, "go")
@@ -647,47 +528,261 @@ func getLatestGoVersionFromTextHTTP() (string, error) {
 	return version, nil
 }
 
+// fetchChecksumSidecar 获取 go.dev 在归档旁提供的 <archive>.sha256 校验文件，返回其中的 SHA-256 值
+func fetchChecksumSidecar(archiveURL string) (string, error) {
+	sidecarURL := archiveURL + ".sha256"
+	if offlineMode && !isLocalURL(sidecarURL) {
+		return "", fmt.Errorf("cannot fetch %s: %w", sidecarURL, errOffline)
+	}
+	resp, err := clientFor(sidecarURL, httpClient).Get(sidecarURL)
+	if err != nil {
+		return "", fmt.Errorf("unable to fetch checksum from %s: %w", sidecarURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch checksum from %s, status code: %d", sidecarURL, resp.StatusCode)
+	}
+
+	// 校验文件很小，限制读取长度以防止异常响应
+	bodyBytes, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return "", fmt.Errorf("failed to read checksum from %s: %w", sidecarURL, err)
+	}
+
+	return parseChecksumFile(bodyBytes, sidecarURL)
+}
+
+// parseChecksumFile 解析 .sha256 校验文件，source 用于错误信息
+// 文件内容可能是单独的哈希值，也可能是 "<hash>  <filename>" 格式
+func parseChecksumFile(data []byte, source string) (string, error) {
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("empty checksum file at %s", source)
+	}
+	checksum := strings.ToLower(fields[0])
+	if len(checksum) != sha256.Size*2 {
+		return "", fmt.Errorf("invalid sha256 checksum in %s: %q", source, fields[0])
+	}
+	if _, err := hex.DecodeString(checksum); err != nil {
+		return "", fmt.Errorf("invalid sha256 checksum in %s: %q", source, fields[0])
+	}
+	return checksum, nil
+}
+
 // downloadFile 下载文件并显示进度条
-func downloadFile(url, filepath string) error {
-	out, err := os.Create(filepath)
+// 数据先写入 <filepath>.part，连接中断时保留该文件，下次调用时通过 Range 请求续传；
+// If-Range 携带上次响应的 ETag / Last-Modified，服务器上的文件已变化时会返回完整内容，从头下载
+// 下载过程中同时计算 SHA-256，若 expectedChecksum 非空且不匹配，则删除已下载文件并返回错误
+func downloadFile(url, filepath, expectedChecksum string) (err error) {
+	if offlineMode && !isLocalURL(url) {
+		return fmt.Errorf("cannot download %s: %w", url, errOffline)
+	}
+	partPath := filepath + partFileSuffix
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
 		return err
 	}
-	defer out.Close()
+	partial := loadPartialDownload(partPath)
+	var offset int64
+	if partial != nil {
+		offset = partial.Size
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", partial.validator())
+		debugPrint("Requesting %s from byte %d (If-Range: %s)", url, offset, partial.validator())
+	}
+	// 分段下载只用于全新的下载；先请求 "bytes=0-"，由响应判断服务器是否支持 Range，不额外发请求
+	trySegments := partial == nil && downloadSegments > 1 && !isLocalURL(url)
+	if trySegments {
+		req.Header.Set("Range", "bytes=0-")
+	}
 
-	resp, err := http.Get(url)
+	resp, err := clientFor(url, downloadClient).Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	// segmentedSize 服务器支持分段下载时的文件大小
+	var segmentedSize int64
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && partial != nil:
+		if start, ok := contentRangeStart(resp.Header.Get("Content-Range")); !ok || start != offset {
+			debugPrint("Unexpected Content-Range %q, restarting download", resp.Header.Get("Content-Range"))
+			resp.Body.Close()
+			discardPartialDownload(partPath)
+			return downloadFile(url, filepath, expectedChecksum)
+		}
+		fmt.Printf("Resuming download at %s\n", formatBytes(offset))
+	case resp.StatusCode == http.StatusPartialContent && trySegments:
+		if start, ok := contentRangeStart(resp.Header.Get("Content-Range")); !ok || start != 0 {
+			return fmt.Errorf("download failed, unexpected Content-Range %q", resp.Header.Get("Content-Range"))
+		}
+		size, ok := contentRangeSize(resp.Header.Get("Content-Range"))
+		switch {
+		case !ok:
+			debugPrint("Unknown file size, downloading in a single stream")
+		case newPartialDownload(url, resp.Header, nil).validator() == "":
+			// 没有 ETag 和 Last-Modified 时无法确认各段来自同一个文件
+			debugPrint("Server sent no ETag or Last-Modified, downloading in a single stream")
+		default:
+			segmentedSize = size
+		}
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && partial != nil:
+		debugPrint("Server rejected range for %s, restarting download", url)
+		resp.Body.Close()
+		discardPartialDownload(partPath)
+		return downloadFile(url, filepath, expectedChecksum)
+	case resp.StatusCode == http.StatusOK:
+		if partial != nil {
+			debugPrint("Server returned the full file (file changed or ranges unsupported), restarting download")
+		}
+		if trySegments {
+			debugPrint("Server does not support range requests, downloading in a single stream")
+		}
+		offset = 0
+	default:
 		return fmt.Errorf("download failed, status code: %d", resp.StatusCode)
 	}
 
+	hasher := sha256.New()
+	var out *os.File
+	if offset > 0 {
+		// 已下载的部分也要计入校验和
+		if out, err = os.OpenFile(partPath, os.O_RDWR, 0644); err == nil {
+			if _, err = io.CopyN(hasher, out, offset); err == nil {
+				err = out.Truncate(offset)
+			}
+			if err != nil {
+				out.Close()
+			}
+		}
+	} else {
+		out, err = os.Create(partPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	// 记录校验信息，供中断后续传时使用；服务器没有提供 ETag 和 Last-Modified 时无法安全续传
+	state := newPartialDownload(url, resp.Header, partial)
+	resumable := state.validator() != ""
+	if resumable {
+		if err := state.save(partPath); err != nil {
+			debugPrint("Failed to record partial download state: %v", err)
+		}
+	} else {
+		removePartialDownloadState(partPath)
+	}
+	defer func() {
+		out.Close()
+		// 校验失败的文件不可信，不能用于续传；无法续传的 .part 文件也没有保留的意义
+		// 其他错误 (例如连接中断) 保留 .part 文件以便续传
+		switch {
+		case err == nil:
+		case errors.Is(err, errChecksumMismatch):
+			debugPrint("Removing untrusted download: %s", partPath)
+			discardPartialDownload(partPath)
+		case !resumable:
+			debugPrint("Server sent no ETag or Last-Modified, removing %s", partPath)
+			discardPartialDownload(partPath)
+		default:
+			fmt.Fprintf(os.Stderr, "Warning: Download of %s interrupted, %s kept in %s to resume later\n", url, formatBytes(fileSize(partPath)), partPath)
+		}
+	}()
+
 	contentLength := resp.ContentLength
 	if contentLength <= 0 {
 		fmt.Println("Warning: Cannot get content length for progress bar")
 	}
 
-	progressBar := &progressBarWriter{Total: contentLength, downloaded: 0, start: time.Now()}
-	reader := io.TeeReader(resp.Body, progressBar)
+	progressBar := &progressBarWriter{Total: offset + contentLength, downloaded: offset, initial: offset, start: time.Now()}
+	if contentLength <= 0 {
+		progressBar.Total = 0
+	}
+
+	var segments []*downloadSegment
+	if segmentedSize > 0 {
+		segments = splitSegments(out, segmentedSize, downloadSegments)
+	}
+	if len(segments) > 1 {
+		debugPrint("Downloading %s in %d segments", formatBytes(segmentedSize), len(segments))
+		err = downloadSegmented(url, resp.Body, segments, state.validator(), progressBar)
+		fmt.Println()
+		if err != nil {
+			// 各段并不连续，只保留从开头连续完成的部分用于续传
+			if terr := out.Truncate(contiguousSize(segments)); terr != nil {
+				debugPrint("Failed to truncate %s: %v", partPath, terr)
+			}
+			return err
+		}
+		// 各段乱序写入，完成后再统一计算校验和
+		if _, err = out.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err = io.Copy(hasher, out); err != nil {
+			return err
+		}
+	} else {
+		dst := io.MultiWriter(out, hasher)
+		reader := io.TeeReader(resp.Body, progressBar)
 
-	_, err = io.Copy(out, reader)
-	fmt.Println()
-	return err
+		_, err = io.Copy(dst, reader)
+		fmt.Println()
+		if err != nil {
+			return err
+		}
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+
+	if expectedChecksum != "" {
+		actualChecksum := hex.EncodeToString(hasher.Sum(nil))
+		if !strings.EqualFold(actualChecksum, expectedChecksum) {
+			return fmt.Errorf("%w for %s: expected sha256 %s, got %s", errChecksumMismatch, url, expectedChecksum, actualChecksum)
+		}
+		fmt.Printf("Checksum verified: sha256 %s\n", actualChecksum)
+	}
+
+	if err = os.Rename(partPath, filepath); err != nil {
+		return err
+	}
+	removePartialDownloadState(partPath)
+	return nil
+}
+
+// fileSHA256 计算文件的 SHA-256 校验和 (十六进制)
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
 }
 
 // progressBarWriter 提供下载进度反馈，实现 io.Writer 接口
+// 分段下载时多个协程同时写入，由 mu 保护
 type progressBarWriter struct {
+	mu         sync.Mutex
 	Total      int64
 	downloaded int64
+	initial    int64 // initial 续传时已下载的字节数，不计入下载速度
 	start      time.Time
 	lastPrint  time.Time
 }
 
 // Write io.Writer 接口方法
 func (pb *progressBarWriter) Write(p []byte) (n int, err error) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
 	n = len(p)
 	pb.downloaded += int64(n)
 
@@ -706,7 +801,7 @@ func (pb *progressBarWriter) printProgress() {
 	} else {
 		percentage := float64(pb.downloaded) / float64(pb.Total) * 100
 		elapsed := time.Since(pb.start)
-		speed := float64(pb.downloaded) / elapsed.Seconds()
+		speed := float64(pb.downloaded-pb.initial) / elapsed.Seconds()
 
 		fmt.Printf("\rDownloading: %.2f%% (%s / %s) Speed: %s/s Elapsed: %s",
 			percentage,
@@ -731,64 +826,3 @@ func formatBytes(bytes int64) string {
 	}
 	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
-
-// extractTarGz 解压 tar.gz 文件到指定目录
-func extractTarGz(filePath, destDir string) error {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	gzr, err := gzip.NewReader(file)
-	if err != nil {
-		return err
-	}
-	defer gzr.Close()
-
-	tr := tar.NewReader(gzr)
-
-	for {
-		header, err := tr.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-
-		target := filepath.Join(destDir, header.Name)
-
-		// 安全检查：确保解压路径在目标目录内
-		if !strings.HasPrefix(target, destDir+string(os.PathSeparator)) {
-			return fmt.Errorf("illegal file path: %s", target)
-		}
-
-		switch header.Typeflag {
-		case tar.TypeDir: // 目录
-			if _, err := os.Stat(target); os.IsNotExist(err) {
-				if err := os.MkdirAll(target, os.FileMode(header.Mode)); err != nil {
-					return err
-				}
-			} else if err != nil {
-				return err
-			} else {
-				debugPrint("Directory %s already exists, setting mode to %v", target, os.FileMode(header.Mode))
-				if err := os.Chmod(target, os.FileMode(header.Mode)); err != nil {
-					return err
-				}
-			}
-		case tar.TypeReg: // 普通文件
-			f, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR|os.O_TRUNC, os.FileMode(header.Mode))
-			if err != nil {
-				return err
-			}
-			defer f.Close()
-
-			if _, err := io.Copy(f, tr); err != nil {
-				return err
-			}
-		}
-	}
-	return nil
-}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// mirrorEnv 未指定 --mirror 时使用的镜像列表 (逗号分隔)
+	mirrorEnv = "GO2V_MIRROR"
+	// mirrorConfigKey 配置文件中指定镜像的 key
+	mirrorConfigKey = "mirror"
+	// mirrorProbeBytes 测速时从每个镜像读取的字节数
+	mirrorProbeBytes = 256 * 1024
+	// mirrorProbeTimeout 单个镜像测速的超时时间
+	mirrorProbeTimeout = 5 * time.Second
+	// mirrorRankingTTL 镜像排名缓存的有效期，网络环境变化后需要重新测速
+	mirrorRankingTTL = 30 * time.Minute
+	// mirrorRankingCacheFile 镜像排名缓存文件名
+	mirrorRankingCacheFile = "mirror-ranking.json"
+	// defaultArchiveSize 元数据中没有归档大小时用于估算下载时间的大小
+	defaultArchiveSize = 64 * 1024 * 1024
+)
+
+// knownMirrors 可以直接按名称使用的公共镜像及其下载地址
+var knownMirrors = map[string]string{
+	goChinaSourceName: goChinaBaseURL,
+	"aliyun":          "https://mirrors.aliyun.com/golang/",
+	"ustc":            "https://mirrors.ustc.edu.cn/golang/",
+}
+
+var (
+	// mirrorSpecs 用户通过 --mirror 指定的镜像，按顺序尝试
+	mirrorSpecs listArgs
+	// noMirrorProbe 配置了多个镜像时不测速，按配置顺序使用
+	noMirrorProbe bool
+)
+
+// downloadMirror 只提供归档下载的镜像 (与 go.dev/dl/ 文件布局相同)
+// 镜像不提供版本信息和校验和，校验和始终来自可信来源的元数据
+type downloadMirror struct {
+	Name    string // Name 用户指定的镜像名称或地址
+	BaseURL string // BaseURL 以 "/" 结尾的下载基础地址
+}
+
+// ArchiveURL 返回镜像上归档文件的下载地址
+func (m downloadMirror) ArchiveURL(filename string) string {
+	return m.BaseURL + filename
+}
+
+// newDownloadMirror 解析单个镜像：已知镜像名称 (golang.google.cn、aliyun、ustc)、
+// http(s) 基础地址，或不带协议的主机名/路径 (例如 mirrors.aliyun.com/golang，默认使用 https)
+func newDownloadMirror(spec string) (downloadMirror, error) {
+	base, known := knownMirrors[spec]
+	switch {
+	case known:
+	case strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://"):
+		base = spec
+	case strings.Contains(spec, "://"):
+		return downloadMirror{}, fmt.Errorf("invalid mirror %q: only http and https are supported", spec)
+	case strings.Contains(strings.Split(spec, "/")[0], "."):
+		base = "https://" + spec
+	default:
+		return downloadMirror{}, fmt.Errorf("unknown mirror %q: use one of %s or a URL", spec, strings.Join(knownMirrorNames(), ", "))
+	}
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	return downloadMirror{Name: spec, BaseURL: base}, nil
+}
+
+// knownMirrorNames 返回已知镜像名称，用于错误提示
+func knownMirrorNames() []string {
+	names := make([]string, 0, len(knownMirrors))
+	for name := range knownMirrors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// configuredMirrors 根据 --mirror (或 GO2V_MIRROR、配置文件中的 mirror) 返回镜像列表
+func configuredMirrors() ([]downloadMirror, error) {
+	var mirrors []downloadMirror
+	for _, spec := range settingValues(mirrorSpecs, mirrorEnv, mirrorConfigKey) {
+		m, err := newDownloadMirror(spec)
+		if err != nil {
+			return nil, err
+		}
+		mirrors = append(mirrors, m)
+	}
+	return mirrors, nil
+}
+
+// candidateDownloadURLs 返回归档的候选下载地址：先按顺序使用镜像，最后使用来源自身的地址
+func candidateDownloadURLs(mirrors []downloadMirror, source sourceChain, filename string) []string {
+	var urls []string
+	seen := map[string]bool{}
+	add := func(u string) {
+		if !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+	for _, m := range mirrors {
+		add(m.ArchiveURL(filename))
+	}
+	for _, u := range source.ArchiveURLs(filename) {
+		add(u)
+	}
+	return urls
+}
+
+// mirrorProbe 单个镜像的测速结果
+type mirrorProbe struct {
+	Mirror     downloadMirror
+	Latency    time.Duration // Latency 从发出请求到收到响应头的时间
+	Throughput float64       // Throughput 读取测速数据时的吞吐量 (字节/秒)
+	Err        error         // Err 镜像不可用或没有该归档时的错误
+}
+
+// estimatedTime 估算从该镜像下载 size 字节所需的时间，不可用的镜像返回最大值
+func (p mirrorProbe) estimatedTime(size int64) time.Duration {
+	if p.Err != nil || p.Throughput <= 0 {
+		return time.Duration(1<<63 - 1)
+	}
+	return p.Latency + time.Duration(float64(size)/p.Throughput*float64(time.Second))
+}
+
+// probeMirror 对镜像上的归档发出一个小范围的 Range 请求，测量延迟和吞吐量
+// 同时确认镜像上确实有该归档
+func probeMirror(m downloadMirror, filename string) mirrorProbe {
+	result := mirrorProbe{Mirror: m}
+	// 测速失败本身就是结果，不重试
+	ctx, cancel := context.WithTimeout(withoutRetries(context.Background()), mirrorProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.ArchiveURL(filename), nil)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", mirrorProbeBytes-1))
+
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer resp.Body.Close()
+	result.Latency = time.Since(start)
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		result.Err = fmt.Errorf("status code %d", resp.StatusCode)
+		return result
+	}
+
+	// 不支持 Range 的服务器会返回完整文件，只读取测速所需的部分
+	readStart := time.Now()
+	n, err := io.Copy(io.Discard, io.LimitReader(resp.Body, mirrorProbeBytes))
+	elapsed := time.Since(readStart)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if n == 0 {
+		result.Err = fmt.Errorf("empty response")
+		return result
+	}
+	if elapsed <= 0 {
+		elapsed = time.Microsecond
+	}
+	result.Throughput = float64(n) / elapsed.Seconds()
+	return result
+}
+
+// mirrorRanking 缓存的镜像排名
+type mirrorRanking struct {
+	Mirrors  []string  `json:"mirrors"`   // Mirrors 参与排名的镜像 (配置顺序)，配置变化时排名失效
+	Ranked   []string  `json:"ranked"`    // Ranked 按估算下载时间从快到慢排列的镜像
+	ProbedAt time.Time `json:"probed_at"` // ProbedAt 测速时间
+}
+
+// mirrorRankingPath 返回镜像排名缓存文件路径
+func mirrorRankingPath() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, mirrorRankingCacheFile), nil
+}
+
+// mirrorBaseURLs 返回镜像的基础地址列表
+func mirrorBaseURLs(mirrors []downloadMirror) []string {
+	urls := make([]string, len(mirrors))
+	for i, m := range mirrors {
+		urls[i] = m.BaseURL
+	}
+	return urls
+}
+
+// loadMirrorRanking 读取与当前镜像配置一致且未过期的排名，没有时返回 nil
+func loadMirrorRanking(mirrors []downloadMirror) []downloadMirror {
+	path, err := mirrorRankingPath()
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var ranking mirrorRanking
+	if err := json.Unmarshal(data, &ranking); err != nil {
+		debugPrint("Ignoring corrupt mirror ranking %s: %v", path, err)
+		return nil
+	}
+	if time.Since(ranking.ProbedAt) >= mirrorRankingTTL ||
+		strings.Join(ranking.Mirrors, " ") != strings.Join(mirrorBaseURLs(mirrors), " ") {
+		return nil
+	}
+
+	byURL := map[string]downloadMirror{}
+	for _, m := range mirrors {
+		byURL[m.BaseURL] = m
+	}
+	ranked := make([]downloadMirror, 0, len(mirrors))
+	for _, u := range ranking.Ranked {
+		m, ok := byURL[u]
+		if !ok {
+			return nil
+		}
+		ranked = append(ranked, m)
+		delete(byURL, u)
+	}
+	if len(byURL) > 0 {
+		return nil
+	}
+	debugPrint("Using mirror ranking from %s (probed %s ago)", path, time.Since(ranking.ProbedAt).Round(time.Second))
+	return ranked
+}
+
+// saveMirrorRanking 缓存镜像排名
+func saveMirrorRanking(mirrors, ranked []downloadMirror) {
+	path, err := mirrorRankingPath()
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(mirrorRanking{
+		Mirrors:  mirrorBaseURLs(mirrors),
+		Ranked:   mirrorBaseURLs(ranked),
+		ProbedAt: time.Now(),
+	})
+	if err == nil {
+		err = writeFileAtomic(path, data)
+	}
+	if err != nil {
+		debugPrint("Failed to cache mirror ranking: %v", err)
+	}
+}
+
+// forgetMirrorRanking 删除缓存的排名，在排名第一的镜像下载失败时调用，下次运行重新测速
+func forgetMirrorRanking() {
+	if path, err := mirrorRankingPath(); err == nil {
+		os.Remove(path)
+	}
+}
+
+// rankMirrors 按估算的下载时间从快到慢排列镜像
+// 并发测速每个镜像，结果缓存 mirrorRankingTTL；size 为归档大小，用于综合延迟和吞吐量
+func rankMirrors(mirrors []downloadMirror, filename string, size int64) []downloadMirror {
+	if ranked := loadMirrorRanking(mirrors); ranked != nil {
+		return ranked
+	}
+	if size <= 0 {
+		size = defaultArchiveSize
+	}
+
+	fmt.Printf("Probing %d mirrors...\n", len(mirrors))
+	probes := make([]mirrorProbe, len(mirrors))
+	var wg sync.WaitGroup
+	for i, m := range mirrors {
+		wg.Add(1)
+		go func(i int, m downloadMirror) {
+			defer wg.Done()
+			probes[i] = probeMirror(m, filename)
+		}(i, m)
+	}
+	wg.Wait()
+
+	// 估算时间相同 (例如都不可用) 时保持配置顺序
+	sort.SliceStable(probes, func(i, j int) bool {
+		return probes[i].estimatedTime(size) < probes[j].estimatedTime(size)
+	})
+
+	ranked := make([]downloadMirror, len(probes))
+	reachable := false
+	for i, p := range probes {
+		ranked[i] = p.Mirror
+		reachable = reachable || p.Err == nil
+		if p.Err != nil {
+			fmt.Printf("  %d. %s: unavailable (%v)\n", i+1, p.Mirror.Name, p.Err)
+		} else {
+			fmt.Printf("  %d. %s: latency %s, %s/s\n", i+1, p.Mirror.Name, p.Latency.Round(time.Millisecond), formatBytes(int64(p.Throughput)))
+		}
+	}
+	// 全部不可用时多半是网络暂时中断，不缓存这次结果
+	if reachable {
+		saveMirrorRanking(mirrors, ranked)
+	}
+	return ranked
+}
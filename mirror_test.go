@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewDownloadMirror(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"aliyun", "https://mirrors.aliyun.com/golang/"},
+		{"golang.google.cn", goChinaBaseURL},
+		{"https://example.com/go", "https://example.com/go/"},
+		{"http://10.0.0.1:8080/dl/", "http://10.0.0.1:8080/dl/"},
+		{"mirrors.example.com/golang", "https://mirrors.example.com/golang/"},
+	}
+	for _, tt := range tests {
+		m, err := newDownloadMirror(tt.spec)
+		if err != nil {
+			t.Errorf("newDownloadMirror(%q) error: %v", tt.spec, err)
+			continue
+		}
+		if m.BaseURL != tt.want || m.Name != tt.spec {
+			t.Errorf("newDownloadMirror(%q) = %+v, want base %q", tt.spec, m, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"ftp://example.com/go", "file:///srv/go", "nosuchmirror"} {
+		if m, err := newDownloadMirror(bad); err == nil {
+			t.Errorf("newDownloadMirror(%q) = %+v, want an error", bad, m)
+		}
+	}
+}
+
+func TestCandidateDownloadURLs(t *testing.T) {
+	source, err := newSource("https://origin.example/dl/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mirrors []downloadMirror
+	for _, spec := range []string{"https://a.example/", "https://origin.example/dl/", "https://a.example"} {
+		m, err := newDownloadMirror(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mirrors = append(mirrors, m)
+	}
+	got := candidateDownloadURLs(mirrors, sourceChain{source}, "go1.22.0.linux-amd64.tar.gz")
+	want := []string{"https://a.example/go1.22.0.linux-amd64.tar.gz", "https://origin.example/dl/go1.22.0.linux-amd64.tar.gz"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("candidateDownloadURLs = %q, want %q", got, want)
+	}
+}
+
+// testMirror 启动一个测速用的镜像服务器，delay 为响应头之前的延迟，chunkDelay 为每写入 64 KiB 后的延迟
+// 返回的计数器记录收到的请求数
+func testMirror(t *testing.T, delay, chunkDelay time.Duration, status int) (downloadMirror, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		time.Sleep(delay)
+		if status != http.StatusPartialContent {
+			w.WriteHeader(status)
+			return
+		}
+		if r.Header.Get("Range") == "" {
+			t.Errorf("probe request has no Range header")
+		}
+		w.WriteHeader(status)
+		chunk := make([]byte, 64*1024)
+		for range mirrorProbeBytes / len(chunk) {
+			w.Write(chunk)
+			w.(http.Flusher).Flush()
+			time.Sleep(chunkDelay)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	m, err := newDownloadMirror(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, &hits
+}
+
+// mirrorNames 返回镜像名称，用于比较排名
+func mirrorNames(mirrors []downloadMirror) []string {
+	names := make([]string, len(mirrors))
+	for i, m := range mirrors {
+		names[i] = m.Name
+	}
+	return names
+}
+
+func TestProbeMirror(t *testing.T) {
+	ok, _ := testMirror(t, 0, 0, http.StatusPartialContent)
+	p := probeMirror(ok, "go.tar.gz")
+	if p.Err != nil || p.Throughput <= 0 {
+		t.Errorf("probe of working mirror = %+v", p)
+	}
+
+	missing, _ := testMirror(t, 0, 0, http.StatusNotFound)
+	if p := probeMirror(missing, "go.tar.gz"); p.Err == nil {
+		t.Errorf("probe of mirror without the archive succeeded: %+v", p)
+	}
+	if got := (mirrorProbe{Err: os.ErrNotExist}).estimatedTime(1); got != time.Duration(1<<63-1) {
+		t.Errorf("estimatedTime of failed probe = %v, want the maximum", got)
+	}
+}
+
+func TestRankMirrors(t *testing.T) {
+	useTestMetadataCache(t, time.Hour)
+	slowStart, slowStartHits := testMirror(t, 300*time.Millisecond, 0, http.StatusPartialContent)
+	slowBody, _ := testMirror(t, 0, 50*time.Millisecond, http.StatusPartialContent)
+	fast, fastHits := testMirror(t, 0, 0, http.StatusPartialContent)
+	broken, _ := testMirror(t, 0, 0, http.StatusNotFound)
+
+	mirrors := []downloadMirror{broken, slowBody, slowStart, fast}
+	want := []string{fast.Name, slowBody.Name, slowStart.Name, broken.Name}
+	// 归档很小时延迟起主要作用，很大时吞吐量起主要作用
+	if got := mirrorNames(rankMirrors(mirrors, "go.tar.gz", 64*1024)); !reflect.DeepEqual(got, want) {
+		t.Errorf("ranking = %q, want %q", got, want)
+	}
+
+	// 第二次使用缓存的排名，不再测速
+	if got := mirrorNames(rankMirrors(mirrors, "go.tar.gz", 64*1024)); !reflect.DeepEqual(got, want) {
+		t.Errorf("cached ranking = %q, want %q", got, want)
+	}
+	if fastHits.Load() != 1 {
+		t.Errorf("fast mirror probed %d times, want 1", fastHits.Load())
+	}
+
+	// 镜像配置变化后重新测速
+	rankMirrors([]downloadMirror{fast, slowStart}, "go.tar.gz", 64*1024)
+	if fastHits.Load() != 2 || slowStartHits.Load() != 2 {
+		t.Errorf("mirrors probed %d and %d times after the configuration changed, want 2", fastHits.Load(), slowStartHits.Load())
+	}
+
+	// 排名第一的镜像失败后丢弃缓存
+	forgetMirrorRanking()
+	if ranked := loadMirrorRanking([]downloadMirror{fast, slowStart}); ranked != nil {
+		t.Errorf("ranking still cached after forgetMirrorRanking: %q", mirrorNames(ranked))
+	}
+}
+
+func TestRankMirrorsLargeArchive(t *testing.T) {
+	useTestMetadataCache(t, time.Hour)
+	slowStart, _ := testMirror(t, 300*time.Millisecond, 0, http.StatusPartialContent)
+	slowBody, _ := testMirror(t, 0, 50*time.Millisecond, http.StatusPartialContent)
+
+	want := []string{slowStart.Name, slowBody.Name}
+	if got := mirrorNames(rankMirrors([]downloadMirror{slowBody, slowStart}, "go.tar.gz", 0)); !reflect.DeepEqual(got, want) {
+		t.Errorf("ranking = %q, want %q", got, want)
+	}
+}
+
+func TestRankMirrorsNotCachedWhenAllDown(t *testing.T) {
+	useTestMetadataCache(t, time.Hour)
+	a, aHits := testMirror(t, 0, 0, http.StatusServiceUnavailable)
+	b, _ := testMirror(t, 0, 0, http.StatusNotFound)
+
+	// 全部不可用时保持配置顺序，且不缓存
+	mirrors := []downloadMirror{a, b}
+	for range 2 {
+		if got := mirrorNames(rankMirrors(mirrors, "go.tar.gz", 64*1024)); !reflect.DeepEqual(got, mirrorNames(mirrors)) {
+			t.Errorf("ranking = %q, want configuration order", got)
+		}
+	}
+	if aHits.Load() != 2 {
+		t.Errorf("mirror probed %d times, want 2", aHits.Load())
+	}
+}
+
+func TestLoadMirrorRanking(t *testing.T) {
+	useTestMetadataCache(t, time.Hour)
+	a, _ := newDownloadMirror("https://a.example/")
+	b, _ := newDownloadMirror("https://b.example/")
+	mirrors := []downloadMirror{a, b}
+
+	write := func(ranking mirrorRanking) {
+		t.Helper()
+		path, err := mirrorRankingPath()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := json.Marshal(ranking)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		ranking mirrorRanking
+		want    []string
+	}{
+		{"fresh", mirrorRanking{Mirrors: mirrorBaseURLs(mirrors), Ranked: []string{b.BaseURL, a.BaseURL}, ProbedAt: time.Now()}, []string{b.Name, a.Name}},
+		{"expired", mirrorRanking{Mirrors: mirrorBaseURLs(mirrors), Ranked: []string{b.BaseURL, a.BaseURL}, ProbedAt: time.Now().Add(-mirrorRankingTTL)}, nil},
+		{"reordered configuration", mirrorRanking{Mirrors: []string{b.BaseURL, a.BaseURL}, Ranked: []string{b.BaseURL, a.BaseURL}, ProbedAt: time.Now()}, nil},
+		{"incomplete", mirrorRanking{Mirrors: mirrorBaseURLs(mirrors), Ranked: []string{b.BaseURL}, ProbedAt: time.Now()}, nil},
+		{"unknown mirror", mirrorRanking{Mirrors: mirrorBaseURLs(mirrors), Ranked: []string{b.BaseURL, "https://c.example/"}, ProbedAt: time.Now()}, nil},
+	}
+	for _, tt := range tests {
+		write(tt.ranking)
+		var got []string
+		if ranked := loadMirrorRanking(mirrors); ranked != nil {
+			got = mirrorNames(ranked)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: loadMirrorRanking = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	path, _ := mirrorRankingPath()
+	if err := os.WriteFile(path, []byte(strings.Repeat("{", 3)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if ranked := loadMirrorRanking(mirrors); ranked != nil {
+		t.Errorf("corrupt ranking loaded: %q", mirrorNames(ranked))
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !unix
+
+package main
+
+// oNoFollow 打开文件时不跟随符号链接 (Fallback for other OS)
+// 该平台没有 O_NOFOLLOW，依靠 O_EXCL 拒绝已存在的路径
+const oNoFollow = 0
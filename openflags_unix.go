@@ -0,0 +1,8 @@
+//go:build unix
+
+package main
+
+import "syscall"
+
+// oNoFollow 打开文件时不跟随符号链接
+const oNoFollow = syscall.O_NOFOLLOW
@@ -0,0 +1,792 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	_ "embed"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// OpenPGP 数据包类型 (RFC 4880 4.3)
+const (
+	pgpPacketSignature = 2
+	pgpPacketPublicKey = 6
+	pgpPacketUserID    = 13
+	pgpPacketPublicSub = 14
+	pgpPacketUserAttr  = 17
+)
+
+// OpenPGP 公钥算法 (RFC 4880 9.1)，目前仅支持 RSA
+const (
+	pgpAlgoRSA         = 1
+	pgpAlgoRSASignOnly = 3
+)
+
+// OpenPGP 签名类型 (RFC 4880 5.2.1)
+const (
+	pgpSigTypeBinary            = 0x00
+	pgpSigTypeGenericCert       = 0x10
+	pgpSigTypePositiveCert      = 0x13
+	pgpSigTypeSubkeyBinding     = 0x18
+	pgpSigTypePrimaryKeyBinding = 0x19
+	pgpSigTypeDirectKey         = 0x1f
+	pgpSigTypeKeyRevocation     = 0x20
+	pgpSigTypeSubkeyRevocation  = 0x28
+)
+
+// pgpKeyFlagSign 密钥用途标志中的 "可用于签名数据" (RFC 4880 5.2.3.21)
+const pgpKeyFlagSign = 0x02
+
+// goReleaseSigningKey 内嵌的 Go 发布签名公钥 (ASCII armor 格式)
+// 可通过 --gpg-keyring 指定其他公钥文件覆盖
+//
+//go:embed keys/go-release-signing-key.asc
+var goReleaseSigningKey []byte
+
+// goReleaseSigningKeyFingerprint 内嵌公钥的主密钥指纹，更新内嵌公钥时必须同时更新
+// 为空或与内嵌公钥不符时不使用内嵌公钥
+const goReleaseSigningKeyFingerprint = ""
+
+// pgpPublicKey 表示 OpenPGP 公钥 (主密钥或子密钥)
+type pgpPublicKey struct {
+	KeyID       uint64
+	Fingerprint string
+	Created     time.Time
+	RSA         *rsa.PublicKey
+	packet      []byte // packet 公钥数据包内容，用于计算密钥签名的哈希
+}
+
+// pgpSignature 表示一个 v4 OpenPGP 签名数据包
+type pgpSignature struct {
+	SigType     byte
+	PubAlgo     byte
+	Hash        crypto.Hash
+	IssuerKeyID uint64
+	Created     time.Time
+	KeyLifetime time.Duration // KeyLifetime 密钥自创建起的有效期，0 表示永不过期
+	KeyFlags    byte
+	hasKeyFlags bool
+	embedded    []byte // embedded 内嵌签名 (子密钥的主密钥绑定签名) 数据包内容
+	hashedData  []byte // 从版本号到 hashed subpackets 结束的原始字节，参与哈希计算
+	hashTag     [2]byte
+	rsaSig      []byte
+}
+
+// allowsSigning 报告自签名或绑定签名是否允许密钥签名数据，没有用途标志时不作限制
+func (sig *pgpSignature) allowsSigning() bool {
+	return !sig.hasKeyFlags || sig.KeyFlags&pgpKeyFlagSign != 0
+}
+
+// keyExpired 报告 key 按自签名或绑定签名 sig 中的有效期在 now 时是否已过期
+func keyExpired(key *pgpPublicKey, sig *pgpSignature, now time.Time) bool {
+	return sig.KeyLifetime > 0 && !now.Before(key.Created.Add(sig.KeyLifetime))
+}
+
+// loadKeyring 加载用于验证签名的公钥环
+// keyringPath 为空时使用内嵌的 Go 发布签名公钥
+func loadKeyring(keyringPath string) ([]*pgpPublicKey, error) {
+	var data []byte
+	if keyringPath != "" {
+		var err error
+		data, err = os.ReadFile(keyringPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keyring %s: %w", keyringPath, err)
+		}
+	} else {
+		if len(bytes.TrimSpace(goReleaseSigningKey)) == 0 || goReleaseSigningKeyFingerprint == "" {
+			return nil, errors.New("this build does not embed the Go release signing key, please specify one with --gpg-keyring")
+		}
+		return loadPinnedKeyring(goReleaseSigningKey, goReleaseSigningKeyFingerprint)
+	}
+
+	keys, err := parsePGPKeyring(data, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no valid RSA signing keys found in keyring (use --debug to see why keys were skipped)")
+	}
+	return keys, nil
+}
+
+// loadPinnedKeyring 解析公钥环，只返回主密钥指纹为 fingerprint 的公钥及其子密钥
+func loadPinnedKeyring(data []byte, fingerprint string) ([]*pgpPublicKey, error) {
+	entities, err := parsePGPEntities(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entities {
+		if e.primary.Fingerprint != fingerprint {
+			continue
+		}
+		keys := e.signingKeys(time.Now())
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("key %s is revoked, expired or has no valid signing keys", fingerprint)
+		}
+		return keys, nil
+	}
+	return nil, fmt.Errorf("keyring does not contain key %s", fingerprint)
+}
+
+// verifyDetachedSignature 使用公钥环验证文件的 OpenPGP 分离签名
+func verifyDetachedSignature(filePath string, signature []byte, keyring []*pgpPublicKey) (*pgpPublicKey, error) {
+	sig, err := parsePGPSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+	if sig.SigType != pgpSigTypeBinary {
+		return nil, fmt.Errorf("unsupported signature type 0x%02x, expected binary document signature", sig.SigType)
+	}
+
+	var signer *pgpPublicKey
+	for _, key := range keyring {
+		if key.KeyID == sig.IssuerKeyID {
+			signer = key
+			break
+		}
+	}
+	if signer == nil {
+		return nil, fmt.Errorf("signature was made by unknown key %016X", sig.IssuerKeyID)
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sig.Hash.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, fmt.Errorf("failed to hash %s: %w", filePath, err)
+	}
+	if err := checkPGPSignature(signer, sig, h); err != nil {
+		return nil, err
+	}
+	return signer, nil
+}
+
+// checkPGPSignature 验证 sig 是 key 对 h 中已写入的内容所做的签名
+func checkPGPSignature(key *pgpPublicKey, sig *pgpSignature, h hash.Hash) error {
+	if sig.PubAlgo != pgpAlgoRSA && sig.PubAlgo != pgpAlgoRSASignOnly {
+		return fmt.Errorf("unsupported signature public key algorithm %d", sig.PubAlgo)
+	}
+
+	// v4 签名的哈希尾部: hashed 部分 + 0x04 0xFF + hashed 部分长度 (RFC 4880 5.2.4)
+	h.Write(sig.hashedData)
+	trailer := []byte{0x04, 0xff, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(trailer[2:], uint32(len(sig.hashedData)))
+	h.Write(trailer)
+	digest := h.Sum(nil)
+
+	if digest[0] != sig.hashTag[0] || digest[1] != sig.hashTag[1] {
+		return errors.New("signature hash prefix mismatch")
+	}
+
+	// 签名 MPI 可能去掉了前导零，需要补齐到模数长度
+	k := key.RSA.Size()
+	if len(sig.rsaSig) > k {
+		return errors.New("signature is longer than the public key modulus")
+	}
+	padded := make([]byte, k)
+	copy(padded[k-len(sig.rsaSig):], sig.rsaSig)
+	if err := rsa.VerifyPKCS1v15(key.RSA, sig.Hash, digest, padded); err != nil {
+		return fmt.Errorf("bad signature from key %016X: %w", key.KeyID, err)
+	}
+	return nil
+}
+
+// checkKeySignature 验证 issuer 对公钥 (及用户 ID) 所做的签名 (RFC 4880 5.2.4)
+// keys 为依次参与哈希的公钥 (主密钥，子密钥绑定时还有子密钥)，userID 为 nil 时不参与哈希
+func checkKeySignature(issuer *pgpPublicKey, sig *pgpSignature, keys []*pgpPublicKey, userID []byte) error {
+	h := sig.Hash.New()
+	for _, k := range keys {
+		h.Write([]byte{0x99, byte(len(k.packet) >> 8), byte(len(k.packet))})
+		h.Write(k.packet)
+	}
+	if userID != nil {
+		h.Write([]byte{0xb4})
+		h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(userID))))
+		h.Write(userID)
+	}
+	return checkPGPSignature(issuer, sig, h)
+}
+
+// fetchSignature 获取归档旁的 <archive>.asc 签名文件
+func fetchSignature(archiveURL string) ([]byte, error) {
+	sigURL := archiveURL + ".asc"
+	if offlineMode && !isLocalURL(sigURL) {
+		return nil, fmt.Errorf("cannot fetch %s: %w", sigURL, errOffline)
+	}
+	resp, err := clientFor(sigURL, httpClient).Get(sigURL)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch signature from %s: %w", sigURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch signature from %s, status code: %d", sigURL, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signature from %s: %w", sigURL, err)
+	}
+	return data, nil
+}
+
+// pgpEntity 公钥环中的一个公钥：主密钥、经过验证的自签名和子密钥
+type pgpEntity struct {
+	primary *pgpPublicKey
+	selfSig *pgpSignature // selfSig 最新的有效自签名，决定主密钥的有效期和用途
+	revoked bool
+	subkeys []*pgpSubkey
+}
+
+// pgpSubkey 子密钥及其经过验证的绑定签名
+type pgpSubkey struct {
+	key        *pgpPublicKey
+	binding    *pgpSignature // binding 主密钥对子密钥最新的有效绑定签名
+	backSigned bool          // backSigned 子密钥对主密钥的反向绑定签名有效，签名子密钥必须具备
+	revoked    bool
+}
+
+// parsePGPKeyring 解析公钥环 (ASCII armor 或二进制)，返回在 now 时可用于验证签名的 RSA 主密钥和子密钥
+// 吊销、过期、没有有效自签名的公钥以及没有有效绑定签名的子密钥会被跳过
+func parsePGPKeyring(data []byte, now time.Time) ([]*pgpPublicKey, error) {
+	entities, err := parsePGPEntities(data)
+	if err != nil {
+		return nil, err
+	}
+	var keys []*pgpPublicKey
+	for _, e := range entities {
+		keys = append(keys, e.signingKeys(now)...)
+	}
+	return keys, nil
+}
+
+// parsePGPEntities 解析公钥环中的公钥，并验证每个公钥的自签名、吊销签名和子密钥绑定签名
+func parsePGPEntities(data []byte) ([]*pgpEntity, error) {
+	raw, err := pgpDearmor(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var entities []*pgpEntity
+	var entity *pgpEntity
+	var subkey *pgpSubkey
+	var userID []byte // userID 当前用户 ID，之后的认证签名针对它
+	skipSigs := false // skipSigs 之后的签名属于无法使用的数据包 (不支持的子密钥、用户属性)
+	err = readPGPPackets(raw, func(tag byte, body []byte) error {
+		switch tag {
+		case pgpPacketPublicKey:
+			entity, subkey, userID, skipSigs = nil, nil, nil, false
+			key, err := parsePGPPublicKey(body)
+			if err != nil {
+				debugPrint("Skipping unsupported public key packet: %v", err)
+				return nil
+			}
+			entity = &pgpEntity{primary: key}
+			entities = append(entities, entity)
+		case pgpPacketUserID:
+			subkey, userID, skipSigs = nil, body, false
+		case pgpPacketUserAttr:
+			subkey, userID, skipSigs = nil, nil, true
+		case pgpPacketPublicSub:
+			subkey, userID, skipSigs = nil, nil, false
+			if entity == nil {
+				return nil
+			}
+			key, err := parsePGPPublicKey(body)
+			if err != nil {
+				debugPrint("Skipping unsupported subkey of %s: %v", entity.primary.Fingerprint, err)
+				skipSigs = true
+				return nil
+			}
+			subkey = &pgpSubkey{key: key}
+			entity.subkeys = append(entity.subkeys, subkey)
+		case pgpPacketSignature:
+			if entity != nil && !skipSigs {
+				entity.addSignature(body, subkey, userID)
+			}
+		}
+		return nil
+	})
+	return entities, err
+}
+
+// addSignature 验证主密钥对自身、用户 ID 或子密钥 subkey 所做的签名并记录结果，其他签名 (如第三方认证) 被忽略
+func (e *pgpEntity) addSignature(body []byte, subkey *pgpSubkey, userID []byte) {
+	sig, err := parsePGPSignaturePacket(body)
+	if err != nil {
+		debugPrint("Skipping signature on key %s: %v", e.primary.Fingerprint, err)
+		return
+	}
+	if sig.IssuerKeyID != e.primary.KeyID {
+		return
+	}
+
+	switch {
+	case subkey != nil:
+		keys := []*pgpPublicKey{e.primary, subkey.key}
+		switch sig.SigType {
+		case pgpSigTypeSubkeyBinding:
+			if err := checkKeySignature(e.primary, sig, keys, nil); err != nil {
+				debugPrint("Ignoring invalid binding signature on subkey %s: %v", subkey.key.Fingerprint, err)
+				return
+			}
+			if subkey.binding == nil || !sig.Created.Before(subkey.binding.Created) {
+				subkey.binding, subkey.backSigned = sig, checkBackSignature(sig, keys)
+			}
+		case pgpSigTypeSubkeyRevocation:
+			if err := checkKeySignature(e.primary, sig, keys, nil); err != nil {
+				debugPrint("Ignoring invalid revocation of subkey %s: %v", subkey.key.Fingerprint, err)
+				return
+			}
+			subkey.revoked = true
+		}
+	case sig.SigType == pgpSigTypeKeyRevocation:
+		if err := checkKeySignature(e.primary, sig, []*pgpPublicKey{e.primary}, nil); err != nil {
+			debugPrint("Ignoring invalid revocation of key %s: %v", e.primary.Fingerprint, err)
+			return
+		}
+		e.revoked = true
+	case sig.SigType == pgpSigTypeDirectKey && userID == nil,
+		sig.SigType >= pgpSigTypeGenericCert && sig.SigType <= pgpSigTypePositiveCert && userID != nil:
+		if err := checkKeySignature(e.primary, sig, []*pgpPublicKey{e.primary}, userID); err != nil {
+			debugPrint("Ignoring invalid self-signature on key %s: %v", e.primary.Fingerprint, err)
+			return
+		}
+		if e.selfSig == nil || !sig.Created.Before(e.selfSig.Created) {
+			e.selfSig = sig
+		}
+	}
+}
+
+// checkBackSignature 验证绑定签名中内嵌的主密钥绑定签名，它由子密钥签发，证明子密钥持有者同意被绑定 (RFC 4880 11.1)
+func checkBackSignature(binding *pgpSignature, keys []*pgpPublicKey) bool {
+	subkey := keys[1]
+	if binding.embedded == nil {
+		return false
+	}
+	back, err := parsePGPSignaturePacket(binding.embedded)
+	if err != nil || back.SigType != pgpSigTypePrimaryKeyBinding || back.IssuerKeyID != subkey.KeyID {
+		return false
+	}
+	return checkKeySignature(subkey, back, keys, nil) == nil
+}
+
+// signingKeys 返回在 now 时可用于验证数据签名的主密钥和子密钥
+func (e *pgpEntity) signingKeys(now time.Time) []*pgpPublicKey {
+	fp := e.primary.Fingerprint
+	switch {
+	case e.revoked:
+		debugPrint("Skipping revoked key %s", fp)
+		return nil
+	case e.selfSig == nil:
+		debugPrint("Skipping key %s: no valid self-signature", fp)
+		return nil
+	case keyExpired(e.primary, e.selfSig, now):
+		debugPrint("Skipping key %s: expired on %s", fp, e.primary.Created.Add(e.selfSig.KeyLifetime).Format(time.DateOnly))
+		return nil
+	}
+
+	var keys []*pgpPublicKey
+	if e.selfSig.allowsSigning() {
+		debugPrint("Loaded OpenPGP public key %s", fp)
+		keys = append(keys, e.primary)
+	}
+	for _, sub := range e.subkeys {
+		subFP := sub.key.Fingerprint
+		switch {
+		case sub.revoked:
+			debugPrint("Skipping revoked subkey %s of %s", subFP, fp)
+		case sub.binding == nil:
+			debugPrint("Skipping subkey %s of %s: no valid binding signature", subFP, fp)
+		case keyExpired(sub.key, sub.binding, now):
+			debugPrint("Skipping subkey %s of %s: expired on %s", subFP, fp, sub.key.Created.Add(sub.binding.KeyLifetime).Format(time.DateOnly))
+		case !sub.binding.allowsSigning():
+			debugPrint("Skipping subkey %s of %s: not a signing key", subFP, fp)
+		case !sub.backSigned:
+			debugPrint("Skipping signing subkey %s of %s: no valid primary key binding signature", subFP, fp)
+		default:
+			debugPrint("Loaded OpenPGP public subkey %s of %s", subFP, fp)
+			keys = append(keys, sub.key)
+		}
+	}
+	return keys
+}
+
+// parsePGPSignature 解析分离签名 (ASCII armor 或二进制)，返回第一个签名数据包
+func parsePGPSignature(data []byte) (*pgpSignature, error) {
+	raw, err := pgpDearmor(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var sig *pgpSignature
+	err = readPGPPackets(raw, func(tag byte, body []byte) error {
+		if tag != pgpPacketSignature || sig != nil {
+			return nil
+		}
+		var err error
+		sig, err = parsePGPSignaturePacket(body)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if sig == nil {
+		return nil, errors.New("no signature packet found")
+	}
+	return sig, nil
+}
+
+// parsePGPPublicKey 解析 v4 公钥数据包 (RFC 4880 5.5.2)
+func parsePGPPublicKey(body []byte) (*pgpPublicKey, error) {
+	if len(body) < 6 {
+		return nil, errors.New("public key packet too short")
+	}
+	if body[0] != 4 {
+		return nil, fmt.Errorf("unsupported public key version %d", body[0])
+	}
+	algo := body[5]
+	if algo != pgpAlgoRSA && algo != pgpAlgoRSASignOnly {
+		return nil, fmt.Errorf("unsupported public key algorithm %d", algo)
+	}
+
+	rest := body[6:]
+	n, rest, err := readMPI(rest)
+	if err != nil {
+		return nil, err
+	}
+	e, _, err := readMPI(rest)
+	if err != nil {
+		return nil, err
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("RSA public exponent too large")
+	}
+
+	// v4 指纹: SHA-1(0x99 || 2 字节长度 || 数据包内容)，Key ID 为指纹的低 64 位
+	h := sha1.New()
+	h.Write([]byte{0x99, byte(len(body) >> 8), byte(len(body))})
+	h.Write(body)
+	fp := h.Sum(nil)
+
+	return &pgpPublicKey{
+		KeyID:       binary.BigEndian.Uint64(fp[12:20]),
+		Fingerprint: strings.ToUpper(hex.EncodeToString(fp)),
+		Created:     time.Unix(int64(binary.BigEndian.Uint32(body[1:5])), 0),
+		RSA: &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(exponent.Int64()),
+		},
+		packet: body,
+	}, nil
+}
+
+// parsePGPSignaturePacket 解析 v4 签名数据包 (RFC 4880 5.2.3)
+func parsePGPSignaturePacket(body []byte) (*pgpSignature, error) {
+	if len(body) < 6 {
+		return nil, errors.New("signature packet too short")
+	}
+	if body[0] != 4 {
+		return nil, fmt.Errorf("unsupported signature version %d", body[0])
+	}
+
+	sig := &pgpSignature{SigType: body[1], PubAlgo: body[2]}
+	switch body[3] {
+	case 2:
+		// SHA-1 已可构造碰撞，不接受 SHA-1 签名
+		return nil, errors.New("SHA-1 signatures are not accepted")
+	case 8:
+		sig.Hash = crypto.SHA256
+	case 9:
+		sig.Hash = crypto.SHA384
+	case 10:
+		sig.Hash = crypto.SHA512
+	case 11:
+		sig.Hash = crypto.SHA224
+	default:
+		return nil, fmt.Errorf("unsupported signature hash algorithm %d", body[3])
+	}
+
+	hashedLen := int(binary.BigEndian.Uint16(body[4:6]))
+	if len(body) < 6+hashedLen+2 {
+		return nil, errors.New("truncated hashed subpackets")
+	}
+	sig.hashedData = body[:6+hashedLen]
+	hashed := body[6 : 6+hashedLen]
+	rest := body[6+hashedLen:]
+
+	unhashedLen := int(binary.BigEndian.Uint16(rest[:2]))
+	if len(rest) < 2+unhashedLen+2 {
+		return nil, errors.New("truncated unhashed subpackets")
+	}
+	unhashed := rest[2 : 2+unhashedLen]
+	rest = rest[2+unhashedLen:]
+
+	copy(sig.hashTag[:], rest[:2])
+	rsaSig, _, err := readMPI(rest[2:])
+	if err != nil {
+		return nil, err
+	}
+	sig.rsaSig = rsaSig
+
+	// 优先使用 hashed 区域中的签发者信息，其次使用 unhashed 区域
+	if err := parseSignatureSubpackets(hashed, sig, true); err != nil {
+		return nil, err
+	}
+	if err := parseSignatureSubpackets(unhashed, sig, false); err != nil {
+		return nil, err
+	}
+	if sig.IssuerKeyID == 0 {
+		return nil, errors.New("signature does not identify its issuer key")
+	}
+	return sig, nil
+}
+
+// parseSignatureSubpackets 从签名子包中提取签发者 Key ID (类型 16)、签发者指纹 (类型 33) 和内嵌签名 (类型 32)
+// hashed 为 true 时还提取受签名保护的创建时间、密钥有效期和密钥用途
+func parseSignatureSubpackets(data []byte, sig *pgpSignature, hashed bool) error {
+	for len(data) > 0 {
+		var length int
+		switch {
+		case data[0] < 192:
+			length = int(data[0])
+			data = data[1:]
+		case data[0] < 255:
+			if len(data) < 2 {
+				return errors.New("truncated signature subpacket")
+			}
+			length = (int(data[0])-192)<<8 + int(data[1]) + 192
+			data = data[2:]
+		default:
+			if len(data) < 5 {
+				return errors.New("truncated signature subpacket")
+			}
+			length = int(binary.BigEndian.Uint32(data[1:5]))
+			data = data[5:]
+		}
+		if length == 0 || length > len(data) {
+			return errors.New("invalid signature subpacket length")
+		}
+		subType, content := data[0]&0x7f, data[1:length]
+		data = data[length:]
+
+		switch subType {
+		case 16:
+			if len(content) == 8 && sig.IssuerKeyID == 0 {
+				sig.IssuerKeyID = binary.BigEndian.Uint64(content)
+			}
+		case 33:
+			// 版本号 4 的指纹为 20 字节，Key ID 为其低 64 位
+			if len(content) == 21 && content[0] == 4 && sig.IssuerKeyID == 0 {
+				sig.IssuerKeyID = binary.BigEndian.Uint64(content[13:21])
+			}
+		case 32:
+			// 内嵌签名自带签名验证，GnuPG 将其放在 unhashed 区域
+			if sig.embedded == nil {
+				sig.embedded = content
+			}
+		}
+		if !hashed {
+			continue
+		}
+		switch subType {
+		case 2:
+			if len(content) == 4 {
+				sig.Created = time.Unix(int64(binary.BigEndian.Uint32(content)), 0)
+			}
+		case 9:
+			if len(content) == 4 {
+				sig.KeyLifetime = time.Duration(binary.BigEndian.Uint32(content)) * time.Second
+			}
+		case 27:
+			if len(content) > 0 {
+				sig.KeyFlags, sig.hasKeyFlags = content[0], true
+			}
+		}
+	}
+	return nil
+}
+
+// readPGPPackets 遍历 OpenPGP 数据包，支持新旧两种包头格式 (RFC 4880 4.2)
+func readPGPPackets(data []byte, fn func(tag byte, body []byte) error) error {
+	for len(data) > 0 {
+		hdr := data[0]
+		if hdr&0x80 == 0 {
+			return errors.New("invalid OpenPGP packet header")
+		}
+
+		var tag byte
+		var length int
+		if hdr&0x40 != 0 {
+			// 新格式包头
+			tag = hdr & 0x3f
+			if len(data) < 2 {
+				return errors.New("truncated OpenPGP packet header")
+			}
+			switch {
+			case data[1] < 192:
+				length = int(data[1])
+				data = data[2:]
+			case data[1] < 224:
+				if len(data) < 3 {
+					return errors.New("truncated OpenPGP packet header")
+				}
+				length = (int(data[1])-192)<<8 + int(data[2]) + 192
+				data = data[3:]
+			case data[1] == 255:
+				if len(data) < 6 {
+					return errors.New("truncated OpenPGP packet header")
+				}
+				length = int(binary.BigEndian.Uint32(data[2:6]))
+				data = data[6:]
+			default:
+				return errors.New("partial body lengths are not supported")
+			}
+		} else {
+			// 旧格式包头
+			tag = (hdr >> 2) & 0x0f
+			switch hdr & 0x03 {
+			case 0:
+				if len(data) < 2 {
+					return errors.New("truncated OpenPGP packet header")
+				}
+				length = int(data[1])
+				data = data[2:]
+			case 1:
+				if len(data) < 3 {
+					return errors.New("truncated OpenPGP packet header")
+				}
+				length = int(binary.BigEndian.Uint16(data[1:3]))
+				data = data[3:]
+			case 2:
+				if len(data) < 5 {
+					return errors.New("truncated OpenPGP packet header")
+				}
+				length = int(binary.BigEndian.Uint32(data[1:5]))
+				data = data[5:]
+			default:
+				length = len(data) - 1
+				data = data[1:]
+			}
+		}
+
+		if length < 0 || length > len(data) {
+			return errors.New("truncated OpenPGP packet")
+		}
+		if err := fn(tag, data[:length]); err != nil {
+			return err
+		}
+		data = data[length:]
+	}
+	return nil
+}
+
+// readMPI 读取一个 OpenPGP 多精度整数 (RFC 4880 3.2)
+func readMPI(data []byte) (value, rest []byte, err error) {
+	if len(data) < 2 {
+		return nil, nil, errors.New("truncated MPI")
+	}
+	bits := int(binary.BigEndian.Uint16(data[:2]))
+	n := (bits + 7) / 8
+	if len(data) < 2+n {
+		return nil, nil, errors.New("truncated MPI")
+	}
+	return data[2 : 2+n], data[2+n:], nil
+}
+
+// pgpDearmor 解码 ASCII armor 格式数据并校验 CRC24，非 armor 数据原样返回
+func pgpDearmor(data []byte) ([]byte, error) {
+	if !bytes.Contains(data, []byte("-----BEGIN PGP ")) {
+		return data, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	var body strings.Builder
+	var checksum string
+	inBlock, inHeaders := false, false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case !inBlock:
+			if strings.HasPrefix(line, "-----BEGIN PGP ") {
+				inBlock, inHeaders = true, true
+			}
+		case strings.HasPrefix(line, "-----END PGP "):
+			inBlock = false
+		case inHeaders:
+			// armor 头部 (如 "Version: ...") 以空行结束
+			if line == "" {
+				inHeaders = false
+			} else if !strings.Contains(line, ":") {
+				inHeaders = false
+				body.WriteString(line)
+			}
+		case strings.HasPrefix(line, "="):
+			checksum = line[1:]
+		default:
+			body.WriteString(line)
+		}
+		if !inBlock && body.Len() > 0 {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(body.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid ASCII armor: %w", err)
+	}
+	if checksum != "" {
+		want, err := base64.StdEncoding.DecodeString(checksum)
+		if err != nil || len(want) != 3 {
+			return nil, errors.New("invalid ASCII armor checksum")
+		}
+		crc := pgpCRC24(decoded)
+		if byte(crc>>16) != want[0] || byte(crc>>8) != want[1] || byte(crc) != want[2] {
+			return nil, errors.New("ASCII armor checksum mismatch")
+		}
+	}
+	return decoded, nil
+}
+
+// pgpCRC24 计算 ASCII armor 使用的 CRC-24 校验值 (RFC 4880 6.1)
+func pgpCRC24(data []byte) uint32 {
+	const (
+		crc24Init = 0xb704ce
+		crc24Poly = 0x1864cfb
+	)
+	crc := uint32(crc24Init)
+	for _, b := range data {
+		crc ^= uint32(b) << 16
+		for i := 0; i < 8; i++ {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= crc24Poly
+			}
+		}
+	}
+	return crc & 0xffffff
+}
@@ -0,0 +1,435 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testPGPKey 测试中生成的 RSA 签名密钥
+type testPGPKey struct {
+	priv   *rsa.PrivateKey
+	packet []byte // packet v4 公钥数据包内容
+	public *pgpPublicKey
+}
+
+// newTestPGPKey 生成一个 RSA 密钥，并构造对应的 v4 公钥数据包
+func newTestPGPKey(t *testing.T) *testPGPKey {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := []byte{4, 0x68, 0x00, 0x00, 0x00, pgpAlgoRSA}
+	body = append(body, testMPI(priv.N.Bytes())...)
+	body = append(body, testMPI(big.NewInt(int64(priv.E)).Bytes())...)
+	public, err := parsePGPPublicKey(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testPGPKey{priv: priv, packet: body, public: public}
+}
+
+// armoredKeyring 返回 ASCII armor 格式的公钥环，包含用户 ID 和允许签名的自签名
+func (k *testPGPKey) armoredKeyring(t *testing.T) []byte {
+	t.Helper()
+	return testArmor("PUBLIC KEY BLOCK", k.selfSigned(t, testSubpacket(27, pgpKeyFlagSign|0x01)))
+}
+
+// selfSigned 返回公钥、用户 ID 和自签名数据包，hashed 为自签名中附加的 hashed 子包 (如密钥用途、有效期)
+func (k *testPGPKey) selfSigned(t *testing.T, hashed []byte) []byte {
+	t.Helper()
+	uid := []byte("go2v test <test@example.com>")
+	out := testPacket(pgpPacketPublicKey, k.packet)
+	out = append(out, testPacket(pgpPacketUserID, uid)...)
+	sig := k.signPacket(t, pgpSigTypePositiveCert, hashed, func(w io.Writer) {
+		writeTestKey(w, k)
+		w.Write([]byte{0xb4})
+		w.Write(binary.BigEndian.AppendUint32(nil, uint32(len(uid))))
+		w.Write(uid)
+	})
+	return append(out, testPacket(pgpPacketSignature, sig)...)
+}
+
+// subkey 返回将 sub 绑定为 k 的子密钥的数据包，hashed 为绑定签名中附加的 hashed 子包
+// backSig 为 true 时在绑定签名中内嵌 sub 签发的反向绑定签名
+func (k *testPGPKey) subkey(t *testing.T, sub *testPGPKey, hashed []byte, backSig bool) []byte {
+	t.Helper()
+	keys := func(w io.Writer) {
+		writeTestKey(w, k)
+		writeTestKey(w, sub)
+	}
+	if backSig {
+		hashed = append(hashed, testSubpacket(32, sub.signPacket(t, pgpSigTypePrimaryKeyBinding, nil, keys)...)...)
+	}
+	out := testPacket(pgpPacketPublicSub, sub.packet)
+	return append(out, testPacket(pgpPacketSignature, k.signPacket(t, pgpSigTypeSubkeyBinding, hashed, keys))...)
+}
+
+// revocation 返回 k 对自身 (sigType 为 0x20) 或紧邻的子密钥 sub (sigType 为 0x28) 的吊销签名数据包
+func (k *testPGPKey) revocation(t *testing.T, sigType byte, sub *testPGPKey) []byte {
+	t.Helper()
+	return testPacket(pgpPacketSignature, k.signPacket(t, sigType, nil, func(w io.Writer) {
+		writeTestKey(w, k)
+		if sub != nil {
+			writeTestKey(w, sub)
+		}
+	}))
+}
+
+// writeTestKey 按密钥签名的哈希格式写入公钥数据包 (RFC 4880 5.2.4)
+func writeTestKey(w io.Writer, k *testPGPKey) {
+	w.Write([]byte{0x99, byte(len(k.packet) >> 8), byte(len(k.packet))})
+	w.Write(k.packet)
+}
+
+// testSubpacket 编码一个签名子包 (长度小于 8384 字节)
+func testSubpacket(subType byte, content ...byte) []byte {
+	n := len(content) + 1
+	var out []byte
+	if n < 192 {
+		out = []byte{byte(n)}
+	} else {
+		out = []byte{byte((n-192)>>8) + 192, byte(n - 192)}
+	}
+	out = append(out, subType)
+	return append(out, content...)
+}
+
+// sign 返回 data 的 ASCII armor 格式分离签名，hashAlgo 为 OpenPGP 哈希算法编号
+func (k *testPGPKey) sign(t *testing.T, data []byte, hashAlgo byte) []byte {
+	t.Helper()
+	body := k.signPacketHash(t, pgpSigTypeBinary, hashAlgo, nil, func(w io.Writer) { w.Write(data) })
+	return testArmor("SIGNATURE", testPacket(pgpPacketSignature, body))
+}
+
+// signPacket 返回 k 使用 SHA-256 签发的 v4 签名数据包内容，content 写入被签名的内容
+func (k *testPGPKey) signPacket(t *testing.T, sigType byte, hashed []byte, content func(io.Writer)) []byte {
+	t.Helper()
+	return k.signPacketHash(t, sigType, 8, hashed, content)
+}
+
+// signPacketHash 与 signPacket 相同，但在签名中声明哈希算法 hashAlgo (实际始终使用 SHA-256 计算)
+func (k *testPGPKey) signPacketHash(t *testing.T, sigType, hashAlgo byte, hashed []byte, content func(io.Writer)) []byte {
+	t.Helper()
+	// hashed 区域: 签名创建时间 (类型 2) 和附加子包
+	hashed = append([]byte{5, 2, 0x68, 0x00, 0x00, 0x00}, hashed...)
+	sigData := []byte{4, sigType, pgpAlgoRSA, hashAlgo, byte(len(hashed) >> 8), byte(len(hashed))}
+	sigData = append(sigData, hashed...)
+
+	h := sha256.New()
+	content(h)
+	h.Write(sigData)
+	trailer := []byte{0x04, 0xff, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(trailer[2:], uint32(len(sigData)))
+	h.Write(trailer)
+	digest := h.Sum(nil)
+	rsaSig, err := rsa.SignPKCS1v15(rand.Reader, k.priv, crypto.SHA256, digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// unhashed 区域: 签发者 Key ID (类型 16)
+	unhashed := []byte{9, 16}
+	unhashed = binary.BigEndian.AppendUint64(unhashed, k.public.KeyID)
+	body := append([]byte{}, sigData...)
+	body = append(body, 0, byte(len(unhashed)))
+	body = append(body, unhashed...)
+	body = append(body, digest[0], digest[1])
+	return append(body, testMPI(rsaSig)...)
+}
+
+// testMPI 编码 OpenPGP 多精度整数
+func testMPI(b []byte) []byte {
+	b = bytes.TrimLeft(b, "\x00")
+	bits := len(b) * 8
+	if len(b) > 0 {
+		bits -= 8 - new(big.Int).SetBytes(b[:1]).BitLen()
+	}
+	return append([]byte{byte(bits >> 8), byte(bits)}, b...)
+}
+
+// testPacket 使用新格式包头 (5 字节长度) 封装数据包
+func testPacket(tag byte, body []byte) []byte {
+	out := []byte{0xc0 | tag, 255}
+	out = binary.BigEndian.AppendUint32(out, uint32(len(body)))
+	return append(out, body...)
+}
+
+// testArmor 返回带 CRC24 校验的 ASCII armor 编码
+func testArmor(blockType string, data []byte) []byte {
+	crc := pgpCRC24(data)
+	var b strings.Builder
+	b.WriteString("-----BEGIN PGP " + blockType + "-----\n\n")
+	enc := base64.StdEncoding.EncodeToString(data)
+	for len(enc) > 64 {
+		b.WriteString(enc[:64] + "\n")
+		enc = enc[64:]
+	}
+	b.WriteString(enc + "\n")
+	b.WriteString("=" + base64.StdEncoding.EncodeToString([]byte{byte(crc >> 16), byte(crc >> 8), byte(crc)}) + "\n")
+	b.WriteString("-----END PGP " + blockType + "-----\n")
+	return []byte(b.String())
+}
+
+func TestVerifyDetachedSignature(t *testing.T) {
+	key := newTestPGPKey(t)
+	otherKey := newTestPGPKey(t)
+	archive := []byte("go1.22.2.linux-amd64.tar.gz contents")
+	signature := key.sign(t, archive, 8)
+
+	// 假的下载服务器，提供归档和 .asc 签名
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/go.tar.gz":
+			w.Write(archive)
+		case "/go.tar.gz.asc":
+			w.Write(signature)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	keyringPath := filepath.Join(dir, "keyring.asc")
+	if err := os.WriteFile(keyringPath, key.armoredKeyring(t), 0644); err != nil {
+		t.Fatal(err)
+	}
+	keyring, err := loadKeyring(keyringPath)
+	if err != nil {
+		t.Fatalf("loadKeyring: %v", err)
+	}
+	fetched, err := fetchSignature(srv.URL + "/go.tar.gz")
+	if err != nil {
+		t.Fatalf("fetchSignature: %v", err)
+	}
+
+	archivePath := filepath.Join(dir, "go.tar.gz")
+	tamperedPath := filepath.Join(dir, "tampered.tar.gz")
+	os.WriteFile(archivePath, archive, 0644)
+	os.WriteFile(tamperedPath, append(bytes.Clone(archive), '!'), 0644)
+
+	// 与签名者 Key ID 相同但公钥不同的密钥，模拟被替换的公钥
+	impostor := &pgpPublicKey{KeyID: key.public.KeyID, RSA: &otherKey.priv.PublicKey}
+
+	tests := []struct {
+		name      string
+		path      string
+		signature []byte
+		keyring   []*pgpPublicKey
+		wantErr   string
+	}{
+		{"valid", archivePath, fetched, keyring, ""},
+		{"tampered archive", tamperedPath, fetched, keyring, "mismatch"},
+		{"unknown key", archivePath, fetched, []*pgpPublicKey{otherKey.public}, "unknown key"},
+		{"wrong key with same key ID", archivePath, fetched, []*pgpPublicKey{impostor}, "bad signature"},
+		{"SHA-1 signature", archivePath, key.sign(t, archive, 2), keyring, "SHA-1"},
+		{"signature by other key", archivePath, otherKey.sign(t, archive, 8), keyring, "unknown key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := verifyDetachedSignature(tt.path, tt.signature, tt.keyring)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if signer.Fingerprint != key.public.Fingerprint {
+					t.Errorf("signer = %s, want %s", signer.Fingerprint, key.public.Fingerprint)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParsePGPKeyring(t *testing.T) {
+	primary := newTestPGPKey(t)
+	sub := newTestPGPKey(t)
+	other := newTestPGPKey(t)
+	now := primary.public.Created.Add(48 * time.Hour)
+	signFlags := testSubpacket(27, pgpKeyFlagSign|0x01)
+	certifyOnly := testSubpacket(27, 0x01)
+	lifetime := func(d time.Duration) []byte {
+		return testSubpacket(9, binary.BigEndian.AppendUint32(nil, uint32(d/time.Second))...)
+	}
+	join := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+
+	// 冒充主密钥签发的自签名：Key ID 相同，但由其他密钥签名
+	forgedSelfSig := other.selfSigned(t, signFlags)
+	forged := join(testPacket(pgpPacketPublicKey, primary.packet), forgedSelfSig[len(testPacket(pgpPacketPublicKey, other.packet)):])
+	forged = bytes.Replace(forged, binary.BigEndian.AppendUint64([]byte{9, 16}, other.public.KeyID), binary.BigEndian.AppendUint64([]byte{9, 16}, primary.public.KeyID), 1)
+	forgedBinding := other.subkey(t, sub, signFlags, true)
+	forgedBinding = bytes.Replace(forgedBinding, binary.BigEndian.AppendUint64([]byte{9, 16}, other.public.KeyID), binary.BigEndian.AppendUint64([]byte{9, 16}, primary.public.KeyID), 1)
+
+	tests := []struct {
+		name    string
+		keyring []byte
+		want    []*testPGPKey
+	}{
+		{"self-signed", primary.selfSigned(t, signFlags), []*testPGPKey{primary}},
+		{"no key flags", primary.selfSigned(t, nil), []*testPGPKey{primary}},
+		{"no self-signature", testPacket(pgpPacketPublicKey, primary.packet), nil},
+		{"forged self-signature", forged, nil},
+		{"revoked", join(testPacket(pgpPacketPublicKey, primary.packet), primary.revocation(t, pgpSigTypeKeyRevocation, nil), primary.selfSigned(t, signFlags)[len(testPacket(pgpPacketPublicKey, primary.packet)):]), nil},
+		{"expired", primary.selfSigned(t, join(signFlags, lifetime(24*time.Hour))), nil},
+		{"not yet expired", primary.selfSigned(t, join(signFlags, lifetime(72*time.Hour))), []*testPGPKey{primary}},
+		{"signing subkey", join(primary.selfSigned(t, certifyOnly), primary.subkey(t, sub, signFlags, true)), []*testPGPKey{sub}},
+		{"subkey without binding signature", join(primary.selfSigned(t, signFlags), testPacket(pgpPacketPublicSub, sub.packet)), []*testPGPKey{primary}},
+		{"subkey with forged binding signature", join(primary.selfSigned(t, signFlags), forgedBinding), []*testPGPKey{primary}},
+		{"signing subkey without back signature", join(primary.selfSigned(t, signFlags), primary.subkey(t, sub, signFlags, false)), []*testPGPKey{primary}},
+		{"encryption subkey", join(primary.selfSigned(t, signFlags), primary.subkey(t, sub, testSubpacket(27, 0x0c), false)), []*testPGPKey{primary}},
+		{"revoked subkey", join(primary.selfSigned(t, signFlags), primary.subkey(t, sub, signFlags, true), primary.revocation(t, pgpSigTypeSubkeyRevocation, sub)), []*testPGPKey{primary}},
+		{"expired subkey", join(primary.selfSigned(t, signFlags), primary.subkey(t, sub, join(signFlags, lifetime(time.Hour)), true)), []*testPGPKey{primary}},
+		{"subkey of revoked key", join(testPacket(pgpPacketPublicKey, primary.packet), primary.revocation(t, pgpSigTypeKeyRevocation, nil), primary.subkey(t, sub, signFlags, true)), nil},
+		{"two keys", join(primary.selfSigned(t, signFlags), other.selfSigned(t, signFlags)), []*testPGPKey{primary, other}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := parsePGPKeyring(testArmor("PUBLIC KEY BLOCK", tt.keyring), now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got, want []string
+			for _, k := range keys {
+				got = append(got, k.Fingerprint)
+			}
+			for _, k := range tt.want {
+				want = append(want, k.public.Fingerprint)
+			}
+			if strings.Join(got, " ") != strings.Join(want, " ") {
+				t.Errorf("keys = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadKeyringEmbedded(t *testing.T) {
+	// 没有内嵌 Go 发布签名公钥和指纹时必须明确要求 --gpg-keyring，而不是在下载后才失败
+	if len(bytes.TrimSpace(goReleaseSigningKey)) == 0 || goReleaseSigningKeyFingerprint == "" {
+		if _, err := loadKeyring(""); err == nil || !strings.Contains(err.Error(), "--gpg-keyring") {
+			t.Fatalf("loadKeyring(\"\") error = %v, want it to mention --gpg-keyring", err)
+		}
+		return
+	}
+	keys, err := loadKeyring("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) == 0 {
+		t.Fatal("embedded keyring has no signing keys")
+	}
+}
+
+func TestLoadPinnedKeyring(t *testing.T) {
+	key := newTestPGPKey(t)
+	other := newTestPGPKey(t)
+	keyring := testArmor("PUBLIC KEY BLOCK", append(other.selfSigned(t, nil), key.selfSigned(t, nil)...))
+
+	keys, err := loadPinnedKeyring(keyring, key.public.Fingerprint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].Fingerprint != key.public.Fingerprint {
+		t.Errorf("pinned keyring returned %d keys, want only %s", len(keys), key.public.Fingerprint)
+	}
+
+	if _, err := loadPinnedKeyring(other.armoredKeyring(t), key.public.Fingerprint); err == nil {
+		t.Error("keyring without the pinned key was accepted")
+	}
+	revoked := testArmor("PUBLIC KEY BLOCK", append(append(testPacket(pgpPacketPublicKey, key.packet), key.revocation(t, pgpSigTypeKeyRevocation, nil)...), key.selfSigned(t, nil)[len(testPacket(pgpPacketPublicKey, key.packet)):]...))
+	if _, err := loadPinnedKeyring(revoked, key.public.Fingerprint); err == nil {
+		t.Error("revoked pinned key was accepted")
+	}
+}
+
+// TestGPGFixtures 使用 GnuPG 生成的公钥和签名 (testdata/openpgp) 验证与真实实现的互操作性
+// keyring.asc: 仅用于认证的 RSA 主密钥和 RSA 签名子密钥，archive.txt.asc 由子密钥签发
+// revoked.asc: 签名后被吊销的公钥；expired.asc: 2020-01-01 创建、有效期 1 天的公钥
+func TestGPGFixtures(t *testing.T) {
+	const (
+		primaryFingerprint = "A6470566EF6EA7B353FEAC55D2DCC023D4F7F44C"
+		subkeyFingerprint  = "1BED59A0D336F815D463B1D2F72ECD20C2512A97"
+	)
+	dir := filepath.Join("testdata", "openpgp")
+	archive := filepath.Join(dir, "archive.txt")
+	readFile := func(name string) []byte {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	keyring, err := loadKeyring(filepath.Join(dir, "keyring.asc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keyring) != 1 || keyring[0].Fingerprint != subkeyFingerprint {
+		t.Fatalf("keyring = %v, want only the signing subkey %s", keyring, subkeyFingerprint)
+	}
+	signer, err := verifyDetachedSignature(archive, readFile("archive.txt.asc"), keyring)
+	if err != nil {
+		t.Fatalf("verifying the gpg signature: %v", err)
+	}
+	if signer.Fingerprint != subkeyFingerprint {
+		t.Errorf("signer = %s, want %s", signer.Fingerprint, subkeyFingerprint)
+	}
+
+	pinned, err := loadPinnedKeyring(readFile("keyring.asc"), primaryFingerprint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifyDetachedSignature(archive, readFile("archive.txt.asc"), pinned); err != nil {
+		t.Errorf("verifying with the pinned keyring: %v", err)
+	}
+
+	tampered := filepath.Join(t.TempDir(), "archive.txt")
+	os.WriteFile(tampered, append(readFile("archive.txt"), '!'), 0644)
+	if _, err := verifyDetachedSignature(tampered, readFile("archive.txt.asc"), keyring); err == nil {
+		t.Error("signature verified for a modified file")
+	}
+
+	for _, name := range []string{"revoked.asc", "expired.asc"} {
+		if keys, err := loadKeyring(filepath.Join(dir, name)); err == nil {
+			t.Errorf("loadKeyring(%s) = %d keys, want an error", name, len(keys))
+		}
+		// 即使跳过有效性检查，签名本身也应能被验证，以确认拒绝的原因是吊销或过期
+		entities, err := parsePGPEntities(readFile(name))
+		if err != nil || len(entities) != 1 {
+			t.Fatalf("parsePGPEntities(%s) = %d entities, %v", name, len(entities), err)
+		}
+		sig := strings.TrimSuffix(name, ".asc")
+		if _, err := verifyDetachedSignature(archive, readFile("archive.txt."+sig+".asc"), []*pgpPublicKey{entities[0].primary}); err != nil {
+			t.Errorf("signature by %s key: %v", sig, err)
+		}
+	}
+	if keys, err := parsePGPKeyring(readFile("expired.asc"), time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)); err != nil || len(keys) != 1 {
+		t.Errorf("expired key before its expiry: %d keys, %v", len(keys), err)
+	}
+}
+
+func TestFetchSignatureNotFound(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	if _, err := fetchSignature(srv.URL + "/go.tar.gz"); err == nil {
+		t.Fatal("expected an error for a missing signature")
+	}
+}
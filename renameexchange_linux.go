@@ -0,0 +1,48 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// renameExchange 对应 renameat2 的 RENAME_EXCHANGE 标志
+const renameExchange = 0x2
+
+// sysRenameat2 各架构上 renameat2 的系统调用号，标准库 syscall 包未导出，0 表示不支持
+var sysRenameat2 = map[string]uintptr{
+	"386":     353,
+	"amd64":   316,
+	"arm":     382,
+	"arm64":   276,
+	"loong64": 276,
+	"riscv64": 276,
+}[runtime.GOARCH]
+
+// exchangePaths 原子地交换 a 和 b 两个路径 (Linux specific)
+// 内核或文件系统不支持 RENAME_EXCHANGE 时返回 errExchangeUnsupported
+func exchangePaths(a, b string) error {
+	if sysRenameat2 == 0 {
+		return errExchangeUnsupported
+	}
+	pa, err := syscall.BytePtrFromString(a)
+	if err != nil {
+		return err
+	}
+	pb, err := syscall.BytePtrFromString(b)
+	if err != nil {
+		return err
+	}
+	dirfd := atFDCWD
+	_, _, errno := syscall.Syscall6(sysRenameat2, uintptr(dirfd), uintptr(unsafe.Pointer(pa)), uintptr(dirfd), uintptr(unsafe.Pointer(pb)), renameExchange, 0)
+	switch errno {
+	case 0:
+		return nil
+	case syscall.ENOSYS, syscall.EINVAL:
+		return errExchangeUnsupported
+	}
+	return &os.LinkError{Op: "renameat2", Old: a, New: b, Err: errno}
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package main
+
+// exchangePaths 原子地交换 a 和 b 两个路径 (Fallback for other OS)
+// 标准库未提供原子交换的接口，调用方改用两次 rename
+func exchangePaths(a, b string) error {
+	return errExchangeUnsupported
+}
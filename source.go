@@ -0,0 +1,344 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// defaultSourceName 默认来源 (go.dev) 的名称，也可以在 --source 中使用
+	defaultSourceName = "go.dev"
+	// sourceEnv 未指定 --source 时使用的来源列表 (逗号分隔)
+	sourceEnv = "GO2V_SOURCE"
+	// sourceConfigKey 配置文件中指定来源的 key
+	sourceConfigKey = "source"
+	// goChinaSourceName Go 官方在中国大陆提供的站点，提供与 go.dev 相同的 JSON API 和归档
+	goChinaSourceName = "golang.google.cn"
+	// goChinaBaseURL golang.google.cn 的下载页面
+	goChinaBaseURL = "https://golang.google.cn/dl/"
+	// goChinaVersionTextURL golang.google.cn 提供最新版本号的纯文本 URL
+	goChinaVersionTextURL = "https://golang.google.cn/VERSION?m=text"
+	// localIndexFileName 本地目录来源中可选的版本列表文件，格式与 go.dev JSON API 相同
+	localIndexFileName = "releases.json"
+)
+
+// Source 提供 Go 版本信息和安装包的来源
+type Source interface {
+	// Name 返回用于提示信息的来源名称
+	Name() string
+	// ListReleases 返回版本列表，includeAll 为 true 时包含预发布版本和已归档的旧版本
+	ListReleases(includeAll bool) ([]GoVersionInfo, error)
+	// LatestVersion 返回最新稳定版本号 (不含 "go" 前缀)
+	LatestVersion() (string, error)
+	// ArchiveURL 返回归档文件的下载地址
+	ArchiveURL(filename string) (string, error)
+	// FetchChecksum 返回归档文件的 SHA-256 校验和
+	FetchChecksum(filename string) (string, error)
+}
+
+// sourceSpecs 用户通过 --source 指定的来源，按顺序尝试
+var sourceSpecs listArgs
+
+// releaseSource 根据 --source (或 GO2V_SOURCE、配置文件中的 source) 返回来源链，未配置时只使用 go.dev
+func releaseSource() (sourceChain, error) {
+	specs := settingValues(sourceSpecs, sourceEnv, sourceConfigKey)
+	if len(specs) == 0 {
+		specs = []string{defaultSourceName}
+	}
+
+	var chain sourceChain
+	for _, spec := range specs {
+		s, err := newSource(spec)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, s)
+	}
+	return chain, nil
+}
+
+// newSource 解析单个来源：go.dev、golang.google.cn、http(s) 基础地址 (与 go.dev/dl/ 布局相同) 或本地目录
+func newSource(spec string) (Source, error) {
+	switch {
+	case spec == defaultSourceName:
+		return newHTTPSource(defaultSourceName, goDevBaseURL, latestVersionTextURL), nil
+	case spec == goChinaSourceName:
+		return newHTTPSource(goChinaSourceName, goChinaBaseURL, goChinaVersionTextURL), nil
+	case strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://"):
+		return newHTTPSource(spec, spec, ""), nil
+	}
+
+	dir := spec
+	if strings.HasPrefix(spec, "file://") {
+		u, err := url.Parse(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid source %q: %v", spec, err)
+		}
+		dir = u.Path
+	}
+	fi, err := os.Stat(dir)
+	if err != nil || !fi.IsDir() {
+		return nil, fmt.Errorf("invalid source %q: expected %q, %q, an http(s) URL or a local directory", spec, defaultSourceName, goChinaSourceName)
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &localDirSource{Dir: abs}, nil
+}
+
+// httpSource 与 go.dev/dl/ 布局相同的 HTTP 来源：
+// <BaseURL>?mode=json 为版本列表，<BaseURL><filename> 为归档，<BaseURL><filename>.sha256 为校验和
+type httpSource struct {
+	name           string
+	BaseURL        string // BaseURL 以 "/" 结尾的基础地址
+	VersionTextURL string // VersionTextURL 返回最新版本号的纯文本地址，可为空
+}
+
+// newHTTPSource 创建 HTTP 来源，baseURL 会补齐结尾的 "/"
+func newHTTPSource(name, baseURL, versionTextURL string) *httpSource {
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	return &httpSource{name: name, BaseURL: baseURL, VersionTextURL: versionTextURL}
+}
+
+// Name 返回来源名称
+func (s *httpSource) Name() string {
+	return s.name
+}
+
+// metadataURL 返回 JSON API 地址
+func (s *httpSource) metadataURL(includeAll bool) string {
+	if includeAll {
+		return s.BaseURL + "?mode=json&include=all"
+	}
+	return s.BaseURL + "?mode=json"
+}
+
+// ListReleases 从 JSON API 获取版本列表 (带缓存)
+func (s *httpSource) ListReleases(includeAll bool) ([]GoVersionInfo, error) {
+	return fetchReleaseList(s.metadataURL(includeAll), s.metadataURL(true))
+}
+
+// LatestVersion 从纯文本接口获取最新版本号
+func (s *httpSource) LatestVersion() (string, error) {
+	if s.VersionTextURL == "" {
+		return "", fmt.Errorf("%s does not provide a latest version endpoint", s.name)
+	}
+	return fetchLatestVersionText(s.VersionTextURL)
+}
+
+// ArchiveURL 返回归档文件的下载地址
+func (s *httpSource) ArchiveURL(filename string) (string, error) {
+	return s.BaseURL + filename, nil
+}
+
+// FetchChecksum 获取归档旁的 .sha256 校验文件
+func (s *httpSource) FetchChecksum(filename string) (string, error) {
+	return fetchChecksumSidecar(s.BaseURL + filename)
+}
+
+// localDirSource 本地目录来源，目录中存放归档文件 (以及可选的 .sha256 校验文件)
+// 目录中有 releases.json 时使用其中的版本列表，否则根据归档文件名生成
+type localDirSource struct {
+	Dir string // Dir 目录的绝对路径
+}
+
+// Name 返回来源名称
+func (s *localDirSource) Name() string {
+	return s.Dir
+}
+
+// ListReleases 返回目录中的版本列表，includeAll 为 false 时只返回稳定版本
+func (s *localDirSource) ListReleases(includeAll bool) ([]GoVersionInfo, error) {
+	var versions []GoVersionInfo
+	indexPath := filepath.Join(s.Dir, localIndexFileName)
+	if data, err := os.ReadFile(indexPath); err == nil {
+		if versions, err = parseGoVersionList(data, indexPath); err != nil {
+			return nil, err
+		}
+	} else if versions, err = s.scanArchives(); err != nil {
+		return nil, err
+	}
+
+	if !includeAll {
+		stable := versions[:0]
+		for _, v := range versions {
+			if v.Stable {
+				stable = append(stable, v)
+			}
+		}
+		versions = stable
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no Go releases found in %s", s.Dir)
+	}
+	return versions, nil
+}
+
+// scanArchives 根据目录中的归档文件名 (例如 go1.22.5.linux-amd64.tar.gz) 生成版本列表
+func (s *localDirSource) scanArchives() ([]GoVersionInfo, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[string]*GoVersionInfo{}
+	var order []string
+	for _, entry := range entries {
+		version, goos, goarch, ok := parseArchiveFilename(entry.Name())
+		if !ok || !entry.Type().IsRegular() {
+			continue
+		}
+		file := GoFileInfo{Filename: entry.Name(), OS: goos, Arch: goarch, Kind: "archive"}
+		if info, err := entry.Info(); err == nil {
+			file.Size = int(info.Size())
+		}
+		if checksum, err := s.FetchChecksum(entry.Name()); err == nil {
+			file.Checksum = checksum
+		}
+
+		v, seen := byVersion[version]
+		if !seen {
+			parsed, _ := parseGoVersion(version)
+			v = &GoVersionInfo{Version: "go" + version, Stable: parsed.PreKind == ""}
+			byVersion[version] = v
+			order = append(order, version)
+		}
+		v.Files = append(v.Files, file)
+	}
+
+	versions := make([]GoVersionInfo, 0, len(order))
+	for _, version := range order {
+		versions = append(versions, *byVersion[version])
+	}
+	sortGoVersionInfos(versions)
+	return versions, nil
+}
+
+// parseArchiveFilename 解析官方归档文件名 go<version>.<os>-<arch>.tar.gz
+func parseArchiveFilename(name string) (version, goos, goarch string, ok bool) {
+	rest, found := strings.CutSuffix(name, ".tar.gz")
+	if !found || !strings.HasPrefix(rest, "go") {
+		return "", "", "", false
+	}
+	i := strings.LastIndex(rest, ".")
+	if i < 0 {
+		return "", "", "", false
+	}
+	version = strings.TrimPrefix(rest[:i], "go")
+	goos, goarch, found = strings.Cut(rest[i+1:], "-")
+	if !found || goos == "" || goarch == "" {
+		return "", "", "", false
+	}
+	if _, err := parseGoVersion(version); err != nil {
+		return "", "", "", false
+	}
+	return version, goos, goarch, true
+}
+
+// LatestVersion 返回目录中最新的稳定版本号
+func (s *localDirSource) LatestVersion() (string, error) {
+	versions, err := s.ListReleases(false)
+	if err != nil {
+		return "", err
+	}
+	sortGoVersionInfos(versions)
+	return strings.TrimPrefix(versions[0].Version, "go"), nil
+}
+
+// ArchiveURL 返回目录中归档文件的 file:// 地址，文件不存在时返回错误
+func (s *localDirSource) ArchiveURL(filename string) (string, error) {
+	path := filepath.Join(s.Dir, filepath.Base(filename))
+	if _, err := os.Stat(path); err != nil {
+		return "", err
+	}
+	// 目录名可能包含空格、# 或 %，需要按 URL 规则转义
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String(), nil
+}
+
+// FetchChecksum 读取目录中归档旁的 .sha256 校验文件
+func (s *localDirSource) FetchChecksum(filename string) (string, error) {
+	path := filepath.Join(s.Dir, filepath.Base(filename)+".sha256")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return parseChecksumFile(data, path)
+}
+
+// sourceChain 按顺序尝试的来源列表，前一个来源失败时使用下一个
+type sourceChain []Source
+
+// Name 返回所有来源的名称
+func (c sourceChain) Name() string {
+	names := make([]string, len(c))
+	for i, s := range c {
+		names[i] = s.Name()
+	}
+	return strings.Join(names, ", ")
+}
+
+// ListReleases 返回第一个可用来源的版本列表
+func (c sourceChain) ListReleases(includeAll bool) ([]GoVersionInfo, error) {
+	return firstSuccessful(c, "list releases", func(s Source) ([]GoVersionInfo, error) {
+		return s.ListReleases(includeAll)
+	})
+}
+
+// LatestVersion 返回第一个可用来源的最新稳定版本号
+func (c sourceChain) LatestVersion() (string, error) {
+	return firstSuccessful(c, "get latest version", Source.LatestVersion)
+}
+
+// ArchiveURL 返回第一个可用来源的归档下载地址
+func (c sourceChain) ArchiveURL(filename string) (string, error) {
+	return firstSuccessful(c, "locate "+filename, func(s Source) (string, error) {
+		return s.ArchiveURL(filename)
+	})
+}
+
+// ArchiveURLs 按来源顺序返回所有可用的归档下载地址，用于下载失败时依次重试
+func (c sourceChain) ArchiveURLs(filename string) []string {
+	var urls []string
+	for _, s := range c {
+		u, err := s.ArchiveURL(filename)
+		if err != nil {
+			debugPrint("Source %s cannot provide %s: %v", s.Name(), filename, err)
+			continue
+		}
+		urls = append(urls, u)
+	}
+	return urls
+}
+
+// FetchChecksum 返回第一个可用来源提供的校验和
+func (c sourceChain) FetchChecksum(filename string) (string, error) {
+	return firstSuccessful(c, "fetch checksum for "+filename, func(s Source) (string, error) {
+		return s.FetchChecksum(filename)
+	})
+}
+
+// firstSuccessful 依次在各来源上执行 op，返回第一个成功的结果；全部失败时汇总错误
+func firstSuccessful[T any](c sourceChain, what string, op func(Source) (T, error)) (T, error) {
+	var zero T
+	var errs []error
+	var msgs []string
+	for _, s := range c {
+		result, err := op(s)
+		if err == nil {
+			return result, nil
+		}
+		debugPrint("Source %s failed to %s: %v", s.Name(), what, err)
+		errs = append(errs, err)
+		msgs = append(msgs, fmt.Sprintf("%s: %v", s.Name(), err))
+	}
+	if len(errs) == 1 {
+		return zero, errs[0]
+	}
+	return zero, fmt.Errorf("all sources failed to %s: %s", what, strings.Join(msgs, "; "))
+}
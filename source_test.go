@@ -0,0 +1,71 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalDirSourceArchiveURLEscapesPath(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "go mirror #1 100%")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	const filename = "go1.22.2.linux-amd64.tar.gz"
+	if err := os.WriteFile(filepath.Join(dir, filename), []byte("archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := newSource(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archiveURL, err := src.ArchiveURL(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := clientFor(archiveURL, httpClient).Get(archiveURL)
+	if err != nil {
+		t.Fatalf("GET %s: %v", archiveURL, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 200 || string(data) != "archive" {
+		t.Fatalf("GET %s = %d %q, want the archive", archiveURL, resp.StatusCode, data)
+	}
+
+	// 转义后的 file:// 地址也可以作为来源
+	again, err := newSource(archiveURL[:len(archiveURL)-len(filename)-1])
+	if err != nil {
+		t.Fatalf("newSource(%q): %v", archiveURL, err)
+	}
+	if got := again.(*localDirSource).Dir; got != dir {
+		t.Errorf("Dir = %q, want %q", got, dir)
+	}
+}
+
+func TestRemoteRedirectToLocalFileRefused(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secret, []byte("local data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "file://"+filepath.ToSlash(secret), http.StatusFound)
+	}))
+	defer srv.Close()
+
+	for name, client := range map[string]*http.Client{"httpClient": httpClient, "downloadClient": downloadClient} {
+		resp, err := client.Get(srv.URL + "/go.tar.gz.sha256")
+		if err == nil {
+			data, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			t.Errorf("%s followed a redirect into file://: status %d, body %q", name, resp.StatusCode, data)
+		}
+	}
+	if _, err := fetchChecksumSidecar(srv.URL + "/go.tar.gz"); err == nil {
+		t.Error("fetchChecksumSidecar accepted a redirect into file://")
+	}
+}
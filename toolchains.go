@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// toolchainsDirName go2v 管理的工具链根目录名
+	toolchainsDirName = "go2v"
+	// versionsDirName 存放各版本 GOROOT 的子目录名
+	versionsDirName = "versions"
+	// currentLinkName 指向当前激活版本的符号链接名
+	currentLinkName = "current"
+	// globalToolchainsParent --root 模式下工具链根目录的父目录
+	globalToolchainsParent = "/usr/local"
+	// legacyUserInstallDir 旧版本 go2v 使用的单版本安装路径 (相对用户主目录)
+	legacyUserInstallDir = ".local/go"
+)
+
+// toolchainStore 管理并排安装的多个 Go 版本
+// 布局: <Root>/versions/go1.22.5, <Root>/current -> versions/go1.22.5
+type toolchainStore struct {
+	Root string // Root 工具链根目录 (例如 ~/.local/go2v)
+}
+
+// newToolchainStore 返回用户级或全局 (--root) 的工具链存储
+func newToolchainStore(homeDir string, global bool) *toolchainStore {
+	if global {
+		return &toolchainStore{Root: filepath.Join(globalToolchainsParent, toolchainsDirName)}
+	}
+	return &toolchainStore{Root: filepath.Join(homeDir, ".local", toolchainsDirName)}
+}
+
+// VersionsDir 返回存放所有版本的目录
+func (s *toolchainStore) VersionsDir() string {
+	return filepath.Join(s.Root, versionsDirName)
+}
+
+// VersionPath 返回指定版本 (例如 "1.22.5") 的 GOROOT 路径
+func (s *toolchainStore) VersionPath(version string) string {
+	return filepath.Join(s.VersionsDir(), "go"+strings.TrimPrefix(version, "go"))
+}
+
+// CurrentLink 返回 current 符号链接的路径，PATH 中配置的是该链接下的 bin 目录
+func (s *toolchainStore) CurrentLink() string {
+	return filepath.Join(s.Root, currentLinkName)
+}
+
+// Installed 返回已安装的版本列表 (不含 "go" 前缀)，按从新到旧排序
+func (s *toolchainStore) Installed() ([]string, error) {
+	entries, err := os.ReadDir(s.VersionsDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for _, entry := range entries {
+		// 跳过安装过程中的暂存目录和备份目录 (例如 go1.22.2.go2v-backup)
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), "go") ||
+			strings.HasPrefix(entry.Name(), stagingDirPrefix) || strings.HasSuffix(entry.Name(), backupDirSuffix) {
+			continue
+		}
+		versions = append(versions, strings.TrimPrefix(entry.Name(), "go"))
+	}
+	sortVersionStrings(versions)
+	return versions, nil
+}
+
+// IsInstalled 判断指定版本是否已安装
+func (s *toolchainStore) IsInstalled(version string) bool {
+	fi, err := os.Stat(s.VersionPath(version))
+	return err == nil && fi.IsDir()
+}
+
+// Active 返回 current 链接指向的版本，未激活任何版本时返回空字符串
+func (s *toolchainStore) Active() (string, error) {
+	target, err := os.Readlink(s.CurrentLink())
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(filepath.Base(target), "go"), nil
+}
+
+// Activate 将 current 链接原子地指向指定版本
+// 先创建临时链接再 rename 覆盖，其他终端中正在运行的构建不会看到中间状态
+func (s *toolchainStore) Activate(version string) error {
+	if !s.IsInstalled(version) {
+		return fmt.Errorf("go%s is not installed in %s", strings.TrimPrefix(version, "go"), s.VersionsDir())
+	}
+
+	// 使用相对路径，使整个工具链目录可以整体移动
+	target := filepath.Join(versionsDirName, filepath.Base(s.VersionPath(version)))
+	link := s.CurrentLink()
+
+	if fi, err := os.Lstat(link); err == nil && fi.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf("%s exists and is not a symlink, refusing to replace it", link)
+	}
+
+	tmpLink := fmt.Sprintf("%s.tmp-%d", link, os.Getpid())
+	os.Remove(tmpLink)
+	if err := os.Symlink(target, tmpLink); err != nil {
+		return fmt.Errorf("failed to create symlink %s: %w", tmpLink, err)
+	}
+	if err := os.Rename(tmpLink, link); err != nil {
+		os.Remove(tmpLink)
+		return fmt.Errorf("failed to switch %s to %s: %w", link, target, err)
+	}
+	debugPrint("Pointed %s at %s", link, target)
+	return nil
+}
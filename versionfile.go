@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// goVersionFileName goenv 使用的版本文件名
+	goVersionFileName = ".go-version"
+	// toolVersionsFileName asdf / mise 使用的版本文件名
+	toolVersionsFileName = ".tool-versions"
+)
+
+// toolVersionsGoNames .tool-versions 中表示 Go 的工具名 (asdf 插件名为 golang，mise 也接受 go)
+var toolVersionsGoNames = []string{"golang", "go"}
+
+// parseGoVersionFile 解析 .go-version 文件，返回第一个非空、非注释行中的版本
+func parseGoVersionFile(data []byte) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return normalizeVersionFileSpec(line)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no version found")
+}
+
+// parseToolVersions 解析 .tool-versions 文件中的 Go 版本
+// 文件中没有 Go 条目时返回 found == false；同一行列出多个版本时只使用第一个
+func parseToolVersions(data []byte) (spec string, found bool, err error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || !isToolVersionsGoName(fields[0]) {
+			continue
+		}
+		if len(fields) < 2 {
+			return "", true, fmt.Errorf("line %d: missing version for %s", lineNum, fields[0])
+		}
+		spec, err := normalizeVersionFileSpec(fields[1])
+		if err != nil {
+			return "", true, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		return spec, true, nil
+	}
+	return "", false, scanner.Err()
+}
+
+// isToolVersionsGoName 判断 .tool-versions 中的工具名是否表示 Go
+func isToolVersionsGoName(name string) bool {
+	for _, n := range toolVersionsGoNames {
+		if name == n {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeVersionFileSpec 将版本文件中的写法转换为版本表达式
+// 接受 "1.22.5"、"go1.22.5"、"1.22"、stable/oldstable/latest 以及 asdf 的 "latest:1.22"
+func normalizeVersionFileSpec(s string) (string, error) {
+	spec := strings.TrimPrefix(s, "go")
+	if prefix, ok := strings.CutPrefix(spec, versionKeywordLatest+":"); ok {
+		spec = strings.TrimPrefix(prefix, "go")
+	}
+
+	switch spec {
+	case versionKeywordStable, versionKeywordOldStable, versionKeywordLatest:
+		return spec, nil
+	}
+	v, err := parseGoVersion(spec)
+	if err != nil {
+		return "", fmt.Errorf("unsupported Go version %q", s)
+	}
+	// "latest:1.22" 只允许版本线
+	if spec != strings.TrimPrefix(s, "go") && !v.IsMinorOnly() {
+		return "", fmt.Errorf("unsupported Go version %q", s)
+	}
+	return spec, nil
+}
+
+// findVersionFile 从 startDir 开始逐级向上查找 .go-version 或带有 Go 条目的 .tool-versions
+// 同一目录中两者都存在时优先使用 .go-version；未找到时返回 found == false
+func findVersionFile(startDir string) (src versionSource, found bool, err error) {
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		return versionSource{}, false, err
+	}
+	for {
+		path := filepath.Join(dir, goVersionFileName)
+		if data, err := os.ReadFile(path); err == nil {
+			debugPrint("Found %s", path)
+			spec, err := parseGoVersionFile(data)
+			if err != nil {
+				return versionSource{}, true, fmt.Errorf("%s: %w", path, err)
+			}
+			return versionSource{Spec: spec, File: path}, true, nil
+		}
+
+		path = filepath.Join(dir, toolVersionsFileName)
+		if data, err := os.ReadFile(path); err == nil {
+			debugPrint("Found %s", path)
+			spec, ok, err := parseToolVersions(data)
+			if err != nil {
+				return versionSource{}, true, fmt.Errorf("%s: %w", path, err)
+			}
+			// 与 asdf 一致：没有 Go 条目时继续向上查找
+			if ok {
+				return versionSource{Spec: spec, File: path}, true, nil
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return versionSource{}, false, nil
+		}
+		dir = parent
+	}
+}
+
+// defaultVersionSource 返回 dir 下未显式指定版本时使用的版本来源
+// 版本文件与 go.mod 中离 dir 更近的优先，同一目录中版本文件优先
+func defaultVersionSource(dir string) (src versionSource, found bool, err error) {
+	fileSrc, fileFound, fileErr := findVersionFile(dir)
+	modSrc, modFound, modErr := versionFromGoMod(dir)
+	if fileErr != nil {
+		return versionSource{}, true, fileErr
+	}
+
+	switch {
+	case fileFound && modFound:
+		// 两个文件都位于 dir 的祖先目录中，路径更长的目录离 dir 更近
+		if len(filepath.Dir(modSrc.File)) > len(filepath.Dir(fileSrc.File)) {
+			return modSrc, true, modErr
+		}
+		return fileSrc, true, nil
+	case fileFound:
+		return fileSrc, true, nil
+	default:
+		return modSrc, modFound, modErr
+	}
+}
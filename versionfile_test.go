@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseGoVersionFile(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{"1.22.5\n", "1.22.5"},
+		{"go1.22.5", "1.22.5"},
+		{"# pinned for CI\n\n  1.21  \n1.20\n", "1.21"},
+		{"stable\n", "stable"},
+		{"latest:1.22\n", "1.22"},
+		{"1.23rc1\n", "1.23rc1"},
+	}
+	for _, tt := range tests {
+		got, err := parseGoVersionFile([]byte(tt.data))
+		if err != nil || got != tt.want {
+			t.Errorf("parseGoVersionFile(%q) = %q, %v; want %q", tt.data, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "# only a comment\n", "system\n", "latest:1.22.3\n", "1.x\n"} {
+		if got, err := parseGoVersionFile([]byte(bad)); err == nil {
+			t.Errorf("parseGoVersionFile(%q) = %q, want an error", bad, got)
+		}
+	}
+}
+
+func TestParseToolVersions(t *testing.T) {
+	tests := []struct {
+		data    string
+		want    string
+		found   bool
+		wantErr bool
+	}{
+		{"nodejs 20.11.0\ngolang 1.22.5\n", "1.22.5", true, false},
+		{"go 1.21.13 1.20.14\n", "1.21.13", true, false}, // 多个版本时使用第一个
+		{"golang latest:1.22 # newest 1.22\n", "1.22", true, false},
+		{"# golang 1.19\nnodejs 20\n", "", false, false},
+		{"golangci-lint 1.55.0\n", "", false, false},
+		{"golang\n", "", true, true},
+		{"golang ref:master\n", "", true, true},
+	}
+	for _, tt := range tests {
+		got, found, err := parseToolVersions([]byte(tt.data))
+		if (err != nil) != tt.wantErr || found != tt.found || got != tt.want {
+			t.Errorf("parseToolVersions(%q) = %q, %v, %v; want %q, %v, error %v", tt.data, got, found, err, tt.want, tt.found, tt.wantErr)
+		}
+	}
+}
+
+// writeTestFiles 在 root 下创建文件，键为相对路径
+func writeTestFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDefaultVersionSource(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"repo/.go-version":                   "1.21\n",
+		"repo/go.mod":                        "module repo\ngo 1.20\n",
+		"repo/svc/go.mod":                    "module svc\ngo 1.22.1\ntoolchain go1.22.5\n",
+		"repo/svc/pinned/.tool-versions":     "golang 1.23.0\n",
+		"repo/other/.tool-versions":          "nodejs 20\n",
+		"repo/both/.go-version":              "1.19\n",
+		"repo/both/.tool-versions":           "golang 1.18\n",
+		"repo/both/go.mod":                   "module both\ngo 1.17\n",
+		"repo/svc/pinned/deeper/placeholder": "",
+	})
+
+	tests := []struct {
+		dir  string
+		spec string
+		file string
+	}{
+		{"repo", "1.21", "repo/.go-version"},                                   // 同一目录中版本文件优先于 go.mod
+		{"repo/svc", "1.22.5", "repo/svc/go.mod"},                              // 更近的 go.mod 优先
+		{"repo/svc/pinned/deeper", "1.23.0", "repo/svc/pinned/.tool-versions"}, // 更近的 .tool-versions 优先
+		{"repo/other", "1.21", "repo/.go-version"},                             // 没有 Go 条目的 .tool-versions 被跳过
+		{"repo/both", "1.19", "repo/both/.go-version"},                         // .go-version 优先于 .tool-versions
+	}
+	for _, tt := range tests {
+		src, found, err := defaultVersionSource(filepath.Join(root, tt.dir))
+		if err != nil || !found {
+			t.Errorf("%s: found %v, error %v", tt.dir, found, err)
+			continue
+		}
+		if src.Spec != tt.spec || src.File != filepath.Join(root, tt.file) {
+			t.Errorf("%s: got %s from %s, want %s from %s", tt.dir, src.Spec, src.File, tt.spec, tt.file)
+		}
+	}
+
+	writeTestFiles(t, root, map[string]string{"broken/.go-version": "not-a-version\n"})
+	if _, _, err := defaultVersionSource(filepath.Join(root, "broken")); err == nil {
+		t.Error("expected an error for an invalid .go-version")
+	}
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// goVersion 表示解析后的 Go 版本号，例如 1.22.5、1.20、1.21rc2
+type goVersion struct {
+	Major    int
+	Minor    int
+	Patch    int
+	HasPatch bool   // HasPatch 版本号中是否写明了补丁号
+	PreKind  string // PreKind 预发布类型 ("beta" 或 "rc")，正式版为空
+	PreNum   int    // PreNum 预发布序号，例如 rc2 中的 2
+}
+
+// parseGoVersion 解析 Go 版本号，可带 "go" 前缀
+// 支持 "1.22.5"、"1.22"、"go1.20"、"1.21rc2"、"1.9beta1" 以及 Go 1.0 的 "go1"
+func parseGoVersion(s string) (goVersion, error) {
+	var v goVersion
+	raw := s
+	s = strings.TrimPrefix(strings.TrimSpace(s), "go")
+
+	// 预发布后缀只出现在 X.Y 之后，例如 1.21rc2
+	for _, kind := range []string{"beta", "rc"} {
+		if i := strings.Index(s, kind); i >= 0 {
+			num, err := strconv.Atoi(s[i+len(kind):])
+			if err != nil || num < 1 {
+				return v, fmt.Errorf("invalid Go version %q", raw)
+			}
+			v.PreKind, v.PreNum = kind, num
+			s = s[:i]
+			break
+		}
+	}
+
+	// Go 1.0 的正式版名为 "go1"
+	if s == "1" && v.PreKind == "" {
+		s = "1.0.0"
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 || len(parts) > 3 || (v.PreKind != "" && len(parts) != 2) {
+		return v, fmt.Errorf("invalid Go version %q", raw)
+	}
+	nums := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || (len(p) > 1 && p[0] == '0') {
+			return v, fmt.Errorf("invalid Go version %q", raw)
+		}
+		nums[i] = n
+	}
+	v.Major, v.Minor = nums[0], nums[1]
+	if len(nums) == 3 {
+		v.Patch, v.HasPatch = nums[2], true
+	}
+	return v, nil
+}
+
+// String 返回官方发布名称 (不含 "go" 前缀)
+// Go 1.21 起首个正式版命名为 X.Y.0，更早的版本为 X.Y
+func (v goVersion) String() string {
+	if v.PreKind != "" {
+		return fmt.Sprintf("%d.%d%s%d", v.Major, v.Minor, v.PreKind, v.PreNum)
+	}
+	if v.Major == 1 && v.Minor == 0 && v.Patch == 0 {
+		return "1"
+	}
+	if v.Patch == 0 && !v.usesZeroPatchName() {
+		return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+	}
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// usesZeroPatchName 判断该版本线的首个正式版是否以 .0 结尾 (Go 1.21 及之后)
+func (v goVersion) usesZeroPatchName() bool {
+	return v.Major > 1 || (v.Major == 1 && v.Minor >= 21)
+}
+
+// IsMinorOnly 判断是否只指定了版本线 (例如 "1.22")
+func (v goVersion) IsMinorOnly() bool {
+	return !v.HasPatch && v.PreKind == ""
+}
+
+// sameLine 判断两个版本是否属于同一版本线 (X.Y)
+func (v goVersion) sameLine(o goVersion) bool {
+	return v.Major == o.Major && v.Minor == o.Minor
+}
+
+// compareGoVersions 比较两个版本，返回 -1、0 或 1
+// 同一版本线中 beta < rc < 正式版 X.Y(.0) < X.Y.1 ...
+func compareGoVersions(a, b goVersion) int {
+	for _, d := range [][2]int{{a.Major, b.Major}, {a.Minor, b.Minor}} {
+		if d[0] != d[1] {
+			return compareInts(d[0], d[1])
+		}
+	}
+	if c := compareInts(preRank(a), preRank(b)); c != 0 {
+		return c
+	}
+	if a.PreKind != "" {
+		return compareInts(a.PreNum, b.PreNum)
+	}
+	return compareInts(a.Patch, b.Patch)
+}
+
+// preRank 预发布类型的排序权重
+func preRank(v goVersion) int {
+	switch v.PreKind {
+	case "beta":
+		return 0
+	case "rc":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// compareInts 比较两个整数，返回 -1、0 或 1
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// sortVersionStrings 将版本号字符串按从新到旧排序，无法解析的排在最后
+func sortVersionStrings(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		a, errA := parseGoVersion(versions[i])
+		b, errB := parseGoVersion(versions[j])
+		if errA != nil || errB != nil {
+			return errA == nil && errB != nil
+		}
+		return compareGoVersions(a, b) > 0
+	})
+}
+
+// sortGoVersionInfos 将版本信息列表按从新到旧排序，预发布版本排在对应正式版之后
+func sortGoVersionInfos(versions []GoVersionInfo) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		a, errA := parseGoVersion(versions[i].Version)
+		b, errB := parseGoVersion(versions[j].Version)
+		if errA != nil || errB != nil {
+			return errA == nil && errB != nil
+		}
+		return compareGoVersions(a, b) > 0
+	})
+}
+
+// 版本选择关键字
+const (
+	// versionKeywordStable 最新稳定版本
+	versionKeywordStable = "stable"
+	// versionKeywordOldStable 上一个版本线的最新稳定版本
+	versionKeywordOldStable = "oldstable"
+	// versionKeywordLatest 最新版本 (未启用 --unstable 时与 stable 相同)
+	versionKeywordLatest = "latest"
+)
+
+// comparator 单个版本比较条件，例如 ">=1.21"
+type comparator struct {
+	op string
+	v  goVersion
+}
+
+// versionConstraint 版本约束，外层为 "||" 分隔的备选项，内层为需同时满足的条件
+type versionConstraint [][]comparator
+
+// isVersionConstraint 判断 spec 是否为约束表达式而非单个版本号
+func isVersionConstraint(spec string) bool {
+	return strings.ContainsAny(spec, "<>=!~^ |")
+}
+
+// parseVersionConstraint 解析版本约束表达式
+// 支持 >=、>、<=、<、=、!= 比较 (空格分隔表示同时满足，"||" 表示或)，
+// ~1.22 (>=1.22.0 <1.23) 和 ^1.21 (>=1.21.0 <2.0)
+func parseVersionConstraint(s string) (versionConstraint, error) {
+	var c versionConstraint
+	for _, alt := range strings.Split(s, "||") {
+		var all []comparator
+		fields := strings.Fields(alt)
+		for i := 0; i < len(fields); i++ {
+			field := fields[i]
+			op := ""
+			for _, candidate := range []string{">=", "<=", "!=", ">", "<", "=", "~", "^"} {
+				if strings.HasPrefix(field, candidate) {
+					op = candidate
+					break
+				}
+			}
+			rest := strings.TrimPrefix(field, op)
+			// 允许运算符与版本号之间有空格，例如 ">= 1.21"
+			if rest == "" && op != "" && i+1 < len(fields) {
+				i++
+				rest = fields[i]
+			}
+			v, err := parseGoVersion(rest)
+			if err != nil {
+				return nil, fmt.Errorf("invalid version constraint %q: %w", s, err)
+			}
+
+			switch op {
+			case "~":
+				all = append(all,
+					comparator{op: ">=", v: v},
+					comparator{op: "<", v: goVersion{Major: v.Major, Minor: v.Minor + 1}})
+			case "^":
+				all = append(all,
+					comparator{op: ">=", v: v},
+					comparator{op: "<", v: goVersion{Major: v.Major + 1}})
+			case "":
+				// 不带运算符的版本号：版本线表示该线任意版本，完整版本号表示精确匹配
+				if v.IsMinorOnly() {
+					all = append(all,
+						comparator{op: ">=", v: v},
+						comparator{op: "<", v: goVersion{Major: v.Major, Minor: v.Minor + 1}})
+				} else {
+					all = append(all, comparator{op: "=", v: v})
+				}
+			default:
+				all = append(all, comparator{op: op, v: v})
+			}
+		}
+		if len(all) == 0 {
+			return nil, fmt.Errorf("invalid version constraint %q: empty alternative", s)
+		}
+		c = append(c, all)
+	}
+	return c, nil
+}
+
+// matches 判断版本是否满足约束
+func (c versionConstraint) matches(v goVersion) bool {
+	for _, all := range c {
+		ok := true
+		for _, cmp := range all {
+			if !cmp.matches(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matches 判断版本是否满足单个比较条件
+func (cmp comparator) matches(v goVersion) bool {
+	r := compareGoVersions(v, cmp.v)
+	switch cmp.op {
+	case ">=":
+		return r >= 0
+	case ">":
+		return r > 0
+	case "<=":
+		return r <= 0
+	case "<":
+		return r < 0
+	case "!=":
+		return r != 0
+	default:
+		return r == 0
+	}
+}
+
+// versionCandidate 参与版本解析的候选版本
+type versionCandidate struct {
+	Version goVersion // Version 解析后的版本号
+	Stable  bool      // Stable 是否为稳定版本
+}
+
+// selectVersion 按版本表达式在候选列表中选择最高的匹配版本，返回其下标
+//   - 完整版本号 (1.22.5、1.20、1.21rc2) 精确匹配
+//   - 版本线 (1.22) 选择该线最新的稳定补丁版本
+//   - 约束表达式 (">=1.21 <1.23"、"~1.22"、"^1.21") 选择满足约束的最高稳定版本
+//   - stable 选择最新稳定版本，oldstable 选择上一个版本线的最新稳定版本
+//   - latest 选择最新版本
+//
+// allowUnstable 为 true 时，版本线、约束表达式和 latest 也会匹配 rc/beta 等预发布版本
+// 没有匹配时返回 -1
+func selectVersion(spec string, candidates []versionCandidate, allowUnstable bool) (int, error) {
+	spec = strings.TrimSpace(spec)
+
+	var match func(have goVersion, stable bool) bool
+	switch {
+	case spec == versionKeywordStable:
+		match = func(have goVersion, stable bool) bool { return stable }
+	case spec == versionKeywordLatest:
+		match = func(have goVersion, stable bool) bool { return stable || allowUnstable }
+	case spec == versionKeywordOldStable:
+		latest, err := selectVersion(versionKeywordStable, candidates, false)
+		if err != nil || latest < 0 {
+			return -1, err
+		}
+		latestVer := candidates[latest].Version
+		match = func(have goVersion, stable bool) bool {
+			return stable && compareGoVersions(have, goVersion{Major: latestVer.Major, Minor: latestVer.Minor}) < 0
+		}
+	case isVersionConstraint(spec):
+		c, err := parseVersionConstraint(spec)
+		if err != nil {
+			return -1, err
+		}
+		match = func(have goVersion, stable bool) bool { return (stable || allowUnstable) && c.matches(have) }
+	default:
+		want, err := parseGoVersion(spec)
+		if err != nil {
+			return -1, err
+		}
+		if want.IsMinorOnly() {
+			match = func(have goVersion, stable bool) bool { return (stable || allowUnstable) && have.sameLine(want) }
+		} else {
+			match = func(have goVersion, stable bool) bool { return compareGoVersions(have, want) == 0 }
+		}
+	}
+
+	best := -1
+	for i, c := range candidates {
+		if !match(c.Version, c.Stable) {
+			continue
+		}
+		if best < 0 || compareGoVersions(c.Version, candidates[best].Version) > 0 {
+			best = i
+		}
+	}
+	return best, nil
+}
+
+// resolveVersion 在版本列表中解析用户指定的版本，只返回带有 goos/goarch 归档的版本
+// 版本表达式的语法见 selectVersion
+func resolveVersion(spec string, versions []GoVersionInfo, goos, goarch string, allowUnstable bool) (GoVersionInfo, GoFileInfo, error) {
+	var infos []GoVersionInfo
+	var files []GoFileInfo
+	var candidates []versionCandidate
+	for _, v := range versions {
+		have, err := parseGoVersion(v.Version)
+		if err != nil {
+			debugPrint("Skipping unparsable version %s: %v", v.Version, err)
+			continue
+		}
+		file, ok := v.archiveFor(goos, goarch)
+		if !ok {
+			debugPrint("Version %s has no archive for %s/%s", v.Version, goos, goarch)
+			continue
+		}
+		infos = append(infos, v)
+		files = append(files, file)
+		candidates = append(candidates, versionCandidate{Version: have, Stable: v.Stable && have.PreKind == ""})
+	}
+
+	i, err := selectVersion(spec, candidates, allowUnstable)
+	if err != nil {
+		return GoVersionInfo{}, GoFileInfo{}, err
+	}
+	if i < 0 {
+		return GoVersionInfo{}, GoFileInfo{}, fmt.Errorf("no release matching %q found for %s/%s", strings.TrimSpace(spec), goos, goarch)
+	}
+	return infos[i], files[i], nil
+}
+
+// resolveInstalledVersion 在已安装的版本中解析版本表达式，返回匹配的最高版本 (不含 "go" 前缀)
+// 已安装的正式版视为稳定版本
+func resolveInstalledVersion(spec string, installed []string, allowUnstable bool) (string, error) {
+	var names []string
+	var candidates []versionCandidate
+	for _, name := range installed {
+		have, err := parseGoVersion(name)
+		if err != nil {
+			debugPrint("Skipping unparsable installed version %s: %v", name, err)
+			continue
+		}
+		names = append(names, name)
+		candidates = append(candidates, versionCandidate{Version: have, Stable: have.PreKind == ""})
+	}
+
+	i, err := selectVersion(spec, candidates, allowUnstable)
+	if err != nil {
+		return "", err
+	}
+	if i < 0 {
+		return "", fmt.Errorf("no installed version matches %q", strings.TrimSpace(spec))
+	}
+	return names[i], nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import "testing"
+
+func TestParseGoVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want goVersion
+	}{
+		{"1.22.5", goVersion{Major: 1, Minor: 22, Patch: 5, HasPatch: true}},
+		{"go1.22.5", goVersion{Major: 1, Minor: 22, Patch: 5, HasPatch: true}},
+		{"1.22", goVersion{Major: 1, Minor: 22}},
+		{"go1.20", goVersion{Major: 1, Minor: 20}},
+		{"1.21.0", goVersion{Major: 1, Minor: 21, HasPatch: true}},
+		{"1.21rc2", goVersion{Major: 1, Minor: 21, PreKind: "rc", PreNum: 2}},
+		{"go1.9beta1", goVersion{Major: 1, Minor: 9, PreKind: "beta", PreNum: 1}},
+		{"go1", goVersion{Major: 1, HasPatch: true}},
+		{" 1.23.1 ", goVersion{Major: 1, Minor: 23, Patch: 1, HasPatch: true}},
+	}
+	for _, tt := range tests {
+		got, err := parseGoVersion(tt.in)
+		if err != nil {
+			t.Errorf("parseGoVersion(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseGoVersion(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "go", "1.x", "1.22.x", "01.22", "1.022", "1.2.3.4", "1.22.5rc1", "1.21rc0", "1.21rc", "1.21alpha1", "-1.2", "latest"} {
+		if v, err := parseGoVersion(in); err == nil {
+			t.Errorf("parseGoVersion(%q) = %+v, want an error", in, v)
+		}
+	}
+}
+
+func TestGoVersionString(t *testing.T) {
+	for in, want := range map[string]string{
+		"go1":       "1",
+		"1.20":      "1.20",
+		"1.20.0":    "1.20",
+		"1.21":      "1.21.0",
+		"1.21.0":    "1.21.0",
+		"go1.22.5":  "1.22.5",
+		"1.21rc2":   "1.21rc2",
+		"1.9beta1":  "1.9beta1",
+		"go2.0.3":   "2.0.3",
+		"go1.10.0 ": "1.10",
+	} {
+		v, err := parseGoVersion(in)
+		if err != nil {
+			t.Fatalf("parseGoVersion(%q): %v", in, err)
+		}
+		if got := v.String(); got != want {
+			t.Errorf("parseGoVersion(%q).String() = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCompareGoVersions(t *testing.T) {
+	// 从旧到新排列
+	ordered := []string{"1", "1.9beta1", "1.9beta2", "1.9rc1", "1.9", "1.9.1", "1.10beta1", "1.10", "1.21rc1", "1.21rc2", "1.21.0", "1.21.1", "1.21.10", "1.22rc1", "2.0.0"}
+	for i := range ordered {
+		for j := range ordered {
+			a, _ := parseGoVersion(ordered[i])
+			b, _ := parseGoVersion(ordered[j])
+			if got, want := compareGoVersions(a, b), compareInts(i, j); got != want {
+				t.Errorf("compareGoVersions(%s, %s) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+
+	// 版本线与其 .0 版本相等
+	a, _ := parseGoVersion("1.21")
+	b, _ := parseGoVersion("1.21.0")
+	if compareGoVersions(a, b) != 0 {
+		t.Error("1.21 and 1.21.0 should compare equal")
+	}
+}
+
+func TestVersionConstraintMatches(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{">=1.21", "1.21.0", true},
+		{">=1.21", "1.20.14", false},
+		{">=1.21", "1.21rc2", false},
+		{">1.21.0", "1.21.1", true},
+		{">1.21.0", "1.21.0", false},
+		{"<1.22", "1.21.13", true},
+		{"<1.22", "1.22rc1", true},
+		{"<1.22", "1.22.0", false},
+		{"<=1.22.1", "1.22.1", true},
+		{"=1.22.1", "1.22.1", true},
+		{"=1.22.1", "1.22.2", false},
+		{"!=1.22.1", "1.22.2", true},
+		{"!=1.22.1", "1.22.1", false},
+		{">=1.21 <1.23", "1.22.7", true},
+		{">=1.21 <1.23", "1.23.0", false},
+		{">= 1.21 < 1.23", "1.21.5", true},
+		{"~1.22", "1.22.0", true},
+		{"~1.22", "1.22.9", true},
+		{"~1.22", "1.23.0", false},
+		{"~1.22.3", "1.22.2", false},
+		{"~1.22.3", "1.22.3", true},
+		{"^1.21", "1.30.1", true},
+		{"^1.21", "1.20.14", false},
+		{"^1.21", "2.0.0", false},
+		{"<1.20 || ~1.22", "1.19.13", true},
+		{"<1.20 || ~1.22", "1.21.0", false},
+		{"<1.20 || ~1.22", "1.22.4", true},
+		{"1.22 || 1.20.3", "1.22.6", true},
+		{"1.22 || 1.20.3", "1.20.3", true},
+		{"1.22 || 1.20.3", "1.20.4", false},
+	}
+	for _, tt := range tests {
+		c, err := parseVersionConstraint(tt.constraint)
+		if err != nil {
+			t.Errorf("parseVersionConstraint(%q): %v", tt.constraint, err)
+			continue
+		}
+		v, err := parseGoVersion(tt.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.matches(v); got != tt.want {
+			t.Errorf("%q matches %s = %v, want %v", tt.constraint, tt.version, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{">=", ">=abc", "~1.x", "1.21 ||", "|| >=1.21", ">=1.21 <"} {
+		if _, err := parseVersionConstraint(bad); err == nil {
+			t.Errorf("parseVersionConstraint(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestSelectVersion(t *testing.T) {
+	var candidates []versionCandidate
+	for _, c := range []struct {
+		version string
+		stable  bool
+	}{
+		{"1.24rc1", false},
+		{"1.23.1", true},
+		{"1.23.0", true},
+		{"1.23rc2", false},
+		{"1.22.7", true},
+		{"1.22.0", true},
+		{"1.21.13", true},
+		{"1.20.14", true},
+	} {
+		v, err := parseGoVersion(c.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		candidates = append(candidates, versionCandidate{Version: v, Stable: c.stable})
+	}
+
+	tests := []struct {
+		spec     string
+		unstable bool
+		want     string // want 期望选中的版本，空字符串表示没有匹配
+	}{
+		{"stable", false, "1.23.1"},
+		{"stable", true, "1.23.1"},
+		{"latest", false, "1.23.1"},
+		{"latest", true, "1.24rc1"},
+		{"oldstable", false, "1.22.7"},
+		{"1.22", false, "1.22.7"},
+		{"go1.22", false, "1.22.7"},
+		{"1.22.0", false, "1.22.0"},
+		{"1.23rc2", false, "1.23rc2"},
+		{"1.24", false, ""},
+		{"1.24", true, "1.24rc1"},
+		{"1.19", false, ""},
+		{">=1.21 <1.23", false, "1.22.7"},
+		{"~1.22", false, "1.22.7"},
+		{"^1.21", false, "1.23.1"},
+		{"^1.21", true, "1.24rc1"},
+		{"<1.21 || ~1.22", false, "1.22.7"},
+		{"!=1.23.1 >=1.23", false, "1.23.0"},
+		{">1.23.1", false, ""},
+		{">1.23.1", true, "1.24rc1"},
+		{"<=1.21.13", false, "1.21.13"},
+	}
+	for _, tt := range tests {
+		i, err := selectVersion(tt.spec, candidates, tt.unstable)
+		if err != nil {
+			t.Errorf("selectVersion(%q, unstable=%v) error: %v", tt.spec, tt.unstable, err)
+			continue
+		}
+		got := ""
+		if i >= 0 {
+			got = candidates[i].Version.String()
+		}
+		if got != tt.want {
+			t.Errorf("selectVersion(%q, unstable=%v) = %q, want %q", tt.spec, tt.unstable, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"1.x", ">=abc", "newest"} {
+		if _, err := selectVersion(bad, candidates, false); err == nil {
+			t.Errorf("selectVersion(%q) succeeded, want an error", bad)
+		}
+	}
+}
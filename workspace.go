@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// goWorkFileName 工作区定义文件名
+const goWorkFileName = "go.work"
+
+// moduleFile 扫描到的 go.mod 或 go.work 文件
+type moduleFile struct {
+	Path       string       // Path 文件的绝对路径
+	Rel        string       // Rel 相对扫描根目录的路径，用于显示
+	Directives goDirectives // Directives 文件中的版本相关指令
+}
+
+// IsWork 判断是否为 go.work 文件
+func (m moduleFile) IsWork() bool {
+	return filepath.Base(m.Path) == goWorkFileName
+}
+
+// workspaceReport 工作区扫描结果
+type workspaceReport struct {
+	Root     string        // Root 扫描的根目录
+	Files    []moduleFile  // Files 扫描到的 go.work 和 go.mod 文件，按路径排序
+	Required versionSource // Required 所有文件中要求的最高版本
+	Found    bool          // Found 是否找到了任何版本要求
+	Issues   []string      // Issues 发现的不一致之处
+}
+
+// scanWorkspace 扫描 root 下的所有 go.work 和 go.mod 文件，计算最高版本要求并检查不一致
+// 与 go 命令一致，跳过以 "." 或 "_" 开头的目录以及 testdata 和 vendor 目录
+func scanWorkspace(root string) (*workspaceReport, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	report := &workspaceReport{Root: root}
+
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			report.Issues = append(report.Issues, fmt.Sprintf("skipped %s: %v", path, err))
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && skipWorkspaceDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != goModFileName && d.Name() != goWorkFileName {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(root, path)
+		directives, err := readGoDirectives(path)
+		if err != nil {
+			report.Issues = append(report.Issues, fmt.Sprintf("skipped %v", err))
+			return nil
+		}
+		debugPrint("Found %s (go %q, toolchain %q)", rel, directives.Go, directives.Toolchain)
+		report.Files = append(report.Files, moduleFile{Path: path, Rel: rel, Directives: directives})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(report.Files, func(i, j int) bool { return report.Files[i].Rel < report.Files[j].Rel })
+	report.findRequired()
+	report.checkConsistency()
+	return report, nil
+}
+
+// skipWorkspaceDir 判断扫描时是否跳过该目录
+func skipWorkspaceDir(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "vendor"
+}
+
+// findRequired 计算所有文件中 go 和 toolchain 指令要求的最高版本
+func (r *workspaceReport) findRequired() {
+	var best goVersion
+	for _, f := range r.Files {
+		for _, c := range []struct{ directive, spec string }{
+			{"go", f.Directives.Go},
+			{"toolchain", f.Directives.Toolchain},
+		} {
+			if c.spec == "" {
+				continue
+			}
+			v, err := parseGoVersion(c.spec)
+			if err != nil {
+				continue
+			}
+			if !r.Found || compareGoVersions(v, best) > 0 {
+				best, r.Found = v, true
+				r.Required = versionSource{Spec: c.spec, File: f.Rel, Directive: c.directive}
+			}
+		}
+	}
+}
+
+// checkConsistency 检查模块之间以及模块与 go.work 之间的版本要求是否一致
+func (r *workspaceReport) checkConsistency() {
+	// 各模块声明的 go 版本 -> 声明该版本的文件
+	goVersions := map[string][]string{}
+	for _, f := range r.Files {
+		d := f.Directives
+		if d.Go == "" {
+			if !f.IsWork() {
+				r.Issues = append(r.Issues, fmt.Sprintf("%s has no go directive (the go command assumes go 1.16)", f.Rel))
+			}
+		} else if !f.IsWork() {
+			goVersions[d.Go] = append(goVersions[d.Go], f.Rel)
+		}
+
+		if d.Go != "" && d.Toolchain != "" {
+			goVer, _ := parseGoVersion(d.Go)
+			toolVer, _ := parseGoVersion(d.Toolchain)
+			if compareGoVersions(toolVer, goVer) < 0 {
+				r.Issues = append(r.Issues, fmt.Sprintf("%s: toolchain go%s is older than go %s", f.Rel, d.Toolchain, d.Go))
+			}
+		}
+
+		if f.IsWork() {
+			r.checkWorkFile(f)
+		}
+	}
+
+	if len(goVersions) > 1 {
+		var specs []string
+		for spec := range goVersions {
+			specs = append(specs, spec)
+		}
+		sortVersionStrings(specs)
+		var parts []string
+		for _, spec := range specs {
+			parts = append(parts, fmt.Sprintf("go %s (%s)", spec, strings.Join(goVersions[spec], ", ")))
+		}
+		r.Issues = append(r.Issues, "modules declare different go versions: "+strings.Join(parts, "; "))
+	}
+}
+
+// checkWorkFile 检查 go.work 的 use 列表以及其 go 版本是否不低于各模块的要求
+func (r *workspaceReport) checkWorkFile(work moduleFile) {
+	workDir := filepath.Dir(work.Path)
+	used := map[string]bool{}
+	for _, use := range work.Directives.Uses {
+		dir := use
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(workDir, dir)
+		}
+		dir = filepath.Clean(dir)
+		used[dir] = true
+		if _, err := os.Stat(filepath.Join(dir, goModFileName)); err != nil {
+			r.Issues = append(r.Issues, fmt.Sprintf("%s uses %s, which has no go.mod", work.Rel, use))
+		}
+	}
+
+	var workVer goVersion
+	hasWorkVer := false
+	if work.Directives.Go != "" {
+		workVer, _ = parseGoVersion(work.Directives.Go)
+		hasWorkVer = true
+	}
+
+	for _, f := range r.Files {
+		if f.IsWork() || !isWithinDir(workDir, f.Path) {
+			continue
+		}
+		dir := filepath.Dir(f.Path)
+		if !used[dir] {
+			r.Issues = append(r.Issues, fmt.Sprintf("%s is not listed in %s", f.Rel, work.Rel))
+			continue
+		}
+		if !hasWorkVer || f.Directives.Go == "" {
+			continue
+		}
+		modVer, _ := parseGoVersion(f.Directives.Go)
+		if compareGoVersions(modVer, workVer) > 0 {
+			r.Issues = append(r.Issues, fmt.Sprintf("%s requires go %s, but %s declares go %s (the go command will refuse to build)", f.Rel, f.Directives.Go, work.Rel, work.Directives.Go))
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDirectiveFields(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"go 1.22", []string{"go", "1.22"}},
+		{"\tuse ./a // main module", []string{"use", "./a"}},
+		{"go 1.22// no space", []string{"go", "1.22"}},
+		{`use "./my module"`, []string{"use", `"./my module"`}},
+		{`use "./a//b" // comment`, []string{"use", `"./a//b"`}},
+		{`use "./say \"hi\"" x`, []string{"use", `"./say \"hi\""`, "x"}},
+		{"use `./raw path`", []string{"use", "`./raw path`"}},
+		{`use "./unterminated`, []string{"use", `"./unterminated`}},
+		{"// only a comment", nil},
+		{"   ", nil},
+	}
+	for _, tt := range tests {
+		if got := directiveFields(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("directiveFields(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseGoWorkUses(t *testing.T) {
+	data := "go 1.22\n\nuse ./a\nuse (\n\t./b // second\n\t\"./with space\"\n\t`./raw`\n)\n"
+	d, err := parseGoDirectives([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"./a", "./b", "./with space", "./raw"}; !reflect.DeepEqual(d.Uses, want) {
+		t.Errorf("Uses = %q, want %q", d.Uses, want)
+	}
+	for _, bad := range []string{"use\n", "use ./a ./b\n", "use \"./unterminated\n"} {
+		if _, err := parseGoDirectives([]byte(bad)); err == nil {
+			t.Errorf("parseGoDirectives(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestScanWorkspace(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"go.work":               "go 1.22\n\nuse (\n\t./a\n\t\"./b c\"\n\t./missing\n)\n",
+		"a/go.mod":              "module a\ngo 1.21\n",
+		"b c/go.mod":            "module bc\ngo 1.22.3\ntoolchain go1.23.0\n",
+		"d/go.mod":              "module d\ngo 1.21\ntoolchain go1.20.5\n",
+		"e/go.mod":              "module e\n",
+		"testdata/go.mod":       "module skipped\ngo 1.99\n",
+		".hidden/go.mod":        "module skipped\ngo 1.99\n",
+		"vendor/x/go.mod":       "module skipped\ngo 1.99\n",
+		"_tools/go.mod":         "module skipped\ngo 1.99\n",
+		"broken/go.mod":         "module broken\ngo one\n",
+		"a/internal/readme.txt": "",
+	})
+
+	report, err := scanWorkspace(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rels []string
+	for _, f := range report.Files {
+		rels = append(rels, filepath.ToSlash(f.Rel))
+	}
+	if want := []string{"a/go.mod", "b c/go.mod", "d/go.mod", "e/go.mod", "go.work"}; !reflect.DeepEqual(rels, want) {
+		t.Errorf("files = %q, want %q", rels, want)
+	}
+	if want := (versionSource{Spec: "1.23.0", File: filepath.Join("b c", "go.mod"), Directive: "toolchain"}); !report.Found || report.Required != want {
+		t.Errorf("required = %+v (found %v), want %+v", report.Required, report.Found, want)
+	}
+
+	wantIssues := []string{
+		"skipped " + filepath.Join(root, "broken", "go.mod"),
+		"e/go.mod has no go directive",
+		"d/go.mod: toolchain go1.20.5 is older than go 1.21",
+		"go.work uses ./missing, which has no go.mod",
+		"d/go.mod is not listed in go.work",
+		"e/go.mod is not listed in go.work",
+		"b c/go.mod requires go 1.22.3, but go.work declares go 1.22",
+		"modules declare different go versions: go 1.22.3 (b c/go.mod); go 1.21 (a/go.mod, d/go.mod)",
+	}
+	for _, want := range wantIssues {
+		want = filepath.FromSlash(want)
+		if !slices.ContainsFunc(report.Issues, func(issue string) bool { return strings.Contains(issue, want) }) {
+			t.Errorf("missing issue %q in:\n%s", want, strings.Join(report.Issues, "\n"))
+		}
+	}
+	if len(report.Issues) != len(wantIssues) {
+		t.Errorf("got %d issues, want %d:\n%s", len(report.Issues), len(wantIssues), strings.Join(report.Issues, "\n"))
+	}
+}
+
+func TestScanWorkspaceMissingRoot(t *testing.T) {
+	if _, err := scanWorkspace(filepath.Join(t.TempDir(), "missing")); !os.IsNotExist(err) {
+		t.Errorf("error = %v, want a not-exist error", err)
+	}
+}